@@ -5,24 +5,26 @@ import (
 	"strings"
 )
 
+// Aggregation modes.
+const (
+	aggregateByMessage  = "message"
+	aggregateByPosition = "position"
+)
+
 type issueKey struct {
 	path      string
 	line, col int
 	message   string
 }
 
-type multiIssue struct {
-	*Issue
-	linterNames []string
-}
-
 // AggregateIssueChan reads issues from a channel, aggregates issues which have
 // the same file, line, vol, and message, and returns aggregated issues on
 // a new channel.
 func AggregateIssueChan(issues chan *Issue) chan *Issue {
 	out := make(chan *Issue, 1000000)
-	issueMap := make(map[issueKey]*multiIssue)
 	go func() {
+		groups := map[issueKey][]*Issue{}
+		keys := []issueKey{}
 		for issue := range issues {
 			key := issueKey{
 				path:    issue.Path.String(),
@@ -30,22 +32,113 @@ func AggregateIssueChan(issues chan *Issue) chan *Issue {
 				col:     issue.Col,
 				message: issue.Message,
 			}
-			if existing, ok := issueMap[key]; ok {
-				existing.linterNames = append(existing.linterNames, issue.Linter)
-			} else {
-				issueMap[key] = &multiIssue{
-					Issue:       issue,
-					linterNames: []string{issue.Linter},
-				}
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
 			}
+			groups[key] = append(groups[key], issue)
 		}
-		for _, multi := range issueMap {
-			issue := multi.Issue
-			sort.Strings(multi.linterNames)
-			issue.Linter = strings.Join(multi.linterNames, ", ")
-			out <- issue
+		for _, key := range keys {
+			out <- mergeIssues(groups[key])
 		}
 		close(out)
 	}()
 	return out
 }
+
+// AggregateIssueChanByPosition reads issues from a channel, aggregates issues
+// in the same file that start within window lines of each other, and returns
+// aggregated issues on a new channel ordered by path and line.
+func AggregateIssueChanByPosition(issues chan *Issue, window int) chan *Issue {
+	out := make(chan *Issue, 1000000)
+	go func() {
+		byPath := map[string][]*Issue{}
+		for issue := range issues {
+			path := issue.Path.String()
+			byPath[path] = append(byPath[path], issue)
+		}
+		paths := make([]string, 0, len(byPath))
+		for path := range byPath {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			pathIssues := byPath[path]
+			sort.SliceStable(pathIssues, func(i, j int) bool {
+				return pathIssues[i].Line < pathIssues[j].Line
+			})
+			group := []*Issue{}
+			for _, issue := range pathIssues {
+				if len(group) > 0 && issue.Line-group[0].Line > window {
+					out <- mergeIssues(group)
+					group = []*Issue{}
+				}
+				group = append(group, issue)
+			}
+			if len(group) > 0 {
+				out <- mergeIssues(group)
+			}
+		}
+		close(out)
+	}()
+	return out
+}
+
+// mergeIssues combines a group of issues into a single issue. The primary
+// issue is chosen by severity and then linter priority, and the messages of
+// the other issues are attached to it as secondary messages.
+func mergeIssues(issues []*Issue) *Issue {
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issuePrecedes(issues[i], issues[j])
+	})
+	primary := issues[0]
+	linterNames := newStringSet()
+	others := []string{}
+	for _, issue := range issues {
+		if linterNames.contains(issue.Linter) {
+			continue
+		}
+		linterNames.add(issue.Linter)
+		if issue != primary {
+			others = append(others, issue.Linter)
+		}
+	}
+	for _, issue := range issues[1:] {
+		if issue.Message == primary.Message && issue.Line == primary.Line && issue.Col == primary.Col {
+			continue
+		}
+		primary.Secondary = append(primary.Secondary, SecondaryMessage{
+			Linter:   issue.Linter,
+			Severity: issue.Severity,
+			Line:     issue.Line,
+			Col:      issue.Col,
+			Message:  issue.Message,
+		})
+	}
+	sort.Strings(others)
+	primary.Linters = append([]string{primary.Linter}, others...)
+	primary.Linter = strings.Join(primary.Linters, ", ")
+	return primary
+}
+
+// issuePrecedes returns true if l should be chosen over r as the primary issue
+// of an aggregate: errors before warnings, then by the position of the linter
+// in LinterPriority, then by linter name.
+func issuePrecedes(l, r *Issue) bool {
+	if l.Severity != r.Severity {
+		return l.Severity == Error
+	}
+	lp, rp := linterPriority(l.Linter), linterPriority(r.Linter)
+	if lp != rp {
+		return lp < rp
+	}
+	return l.Linter < r.Linter
+}
+
+func linterPriority(linter string) int {
+	for i, name := range config.LinterPriority {
+		if name == linter {
+			return i
+		}
+	}
+	return len(config.LinterPriority)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func aggregateIssues(aggregate func(chan *Issue) chan *Issue, issues ...*Issue) []*Issue {
+	in := make(chan *Issue, len(issues))
+	for _, issue := range issues {
+		in <- issue
+	}
+	close(in)
+	out := []*Issue{}
+	for issue := range aggregate(in) {
+		out = append(out, issue)
+	}
+	return out
+}
+
+func TestAggregateIssueChan(t *testing.T) {
+	path := newIssuePath("", "a.go")
+	issues := aggregateIssues(AggregateIssueChan,
+		&Issue{Linter: "vet", Severity: Warning, Path: path, Line: 1, Message: "unused"},
+		&Issue{Linter: "golint", Severity: Warning, Path: path, Line: 1, Message: "unused"},
+		&Issue{Linter: "golint", Severity: Warning, Path: path, Line: 2, Message: "unused"},
+	)
+	require.Len(t, issues, 2)
+	assert.Equal(t, "golint, vet", issues[0].Linter)
+	assert.Equal(t, []string{"golint", "vet"}, issues[0].Linters)
+	assert.Empty(t, issues[0].Secondary)
+	assert.Equal(t, "golint", issues[1].Linter)
+
+	data, err := json.Marshal(issues[0])
+	require.NoError(t, err)
+	assert.Equal(t, `{"linter":"golint","severity":"warning","path":"a.go","line":1,"col":0,"message":"unused","linters":["golint","vet"]}`, string(data))
+}
+
+func TestAggregateIssueChanByPosition(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.LinterPriority = []string{"staticcheck", "vet"}
+
+	a, b := newIssuePath("", "a.go"), newIssuePath("", "b.go")
+	byPosition := func(window int) func(chan *Issue) chan *Issue {
+		return func(issues chan *Issue) chan *Issue {
+			return AggregateIssueChanByPosition(issues, window)
+		}
+	}
+	input := func() []*Issue {
+		return []*Issue{
+			{Linter: "golint", Severity: Warning, Path: a, Line: 3, Message: "should not use dot imports"},
+			{Linter: "vet", Severity: Warning, Path: a, Line: 3, Col: 2, Message: "dot import"},
+			{Linter: "staticcheck", Severity: Warning, Path: a, Line: 4, Message: "should not use dot imports (ST1001)"},
+			{Linter: "errcheck", Severity: Error, Path: b, Line: 1, Message: "unchecked error"},
+		}
+	}
+
+	issues := aggregateIssues(byPosition(0), input()...)
+	require.Len(t, issues, 3)
+	assert.Equal(t, "vet, golint", issues[0].Linter)
+	require.Len(t, issues[0].Secondary, 1)
+	assert.Equal(t, "a.go:3::warning: should not use dot imports (golint)", issues[0].SecondaryIssues()[0].String())
+	assert.Equal(t, "staticcheck", issues[1].Linter)
+	assert.Equal(t, "errcheck", issues[2].Linter)
+
+	issues = aggregateIssues(byPosition(1), input()...)
+	require.Len(t, issues, 2)
+	assert.Equal(t, "should not use dot imports (ST1001)", issues[0].Message)
+	assert.Equal(t, []string{"staticcheck", "golint", "vet"}, issues[0].Linters)
+	assert.Len(t, issues[0].Secondary, 2)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/xml"
 	"fmt"
+	"io"
 
 	kingpin "gopkg.in/alecthomas/kingpin.v3-unstable"
 )
@@ -26,40 +27,33 @@ type checkstyleError struct {
 	Source   string `xml:"source,attr"`
 }
 
-func outputToCheckstyle(issues chan *Issue) int {
-	var lastFile *checkstyleFile
+func outputToCheckstyle(w io.Writer, issues chan *Issue) {
 	out := checkstyleOutput{
 		Version: "5.0",
 	}
-	status := 0
+	files := map[string]*checkstyleFile{}
 	for issue := range issues {
 		path := issue.Path.Relative()
-		if lastFile != nil && lastFile.Name != path {
-			out.Files = append(out.Files, lastFile)
-			lastFile = nil
-		}
-		if lastFile == nil {
-			lastFile = &checkstyleFile{Name: path}
+		file, ok := files[path]
+		if !ok {
+			file = &checkstyleFile{Name: path}
+			files[path] = file
+			out.Files = append(out.Files, file)
 		}
 
-		if config.Errors && issue.Severity != Error {
-			continue
+		message := issue.Message
+		if related := issue.UnmentionedRelated(); len(related) > 0 {
+			message += " (related: " + joinLocations(related) + ")"
 		}
-
-		lastFile.Errors = append(lastFile.Errors, &checkstyleError{
+		file.Errors = append(file.Errors, &checkstyleError{
 			Column:   issue.Col,
 			Line:     issue.Line,
-			Message:  issue.Message,
+			Message:  message,
 			Severity: string(issue.Severity),
 			Source:   issue.Linter,
 		})
-		status = 1
-	}
-	if lastFile != nil {
-		out.Files = append(out.Files, lastFile)
 	}
 	d, err := xml.Marshal(&out)
 	kingpin.FatalIfError(err, "")
-	fmt.Printf("%s%s\n", xml.Header, d)
-	return status
+	fmt.Fprintf(w, "%s%s\n", xml.Header, d)
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	kingpin "gopkg.in/alecthomas/kingpin.v3-unstable"
+)
+
+type codeClimateIssue struct {
+	Type        string              `json:"type"`
+	CheckName   string              `json:"check_name"`
+	Description string              `json:"description"`
+	Categories  []string            `json:"categories"`
+	Location    codeClimateLocation `json:"location"`
+	Severity    string              `json:"severity"`
+	Fingerprint string              `json:"fingerprint"`
+}
+
+type codeClimateLocation struct {
+	Path  string           `json:"path"`
+	Lines codeClimateLines `json:"lines"`
+}
+
+type codeClimateLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end,omitempty"`
+}
+
+// codeClimateFingerprinter generates fingerprints that identify an issue
+// across runs. They are derived from the linter, path, normalised message and
+// source line rather than the line number, so they are unaffected by lines
+// being added or removed elsewhere in the file, including positions quoted in
+// messages by linters such as dupl and vetshadow. Identical issues are
+// numbered in order to keep their fingerprints unique.
+type codeClimateFingerprinter struct {
+	lines sourceLines
+	seen  map[string]int
+}
+
+func newCodeClimateFingerprinter() *codeClimateFingerprinter {
+	return &codeClimateFingerprinter{lines: sourceLines{}, seen: map[string]int{}}
+}
+
+func (f *codeClimateFingerprinter) fingerprint(issue *Issue) string {
+	key := strings.Join([]string{
+		issue.Linter,
+		issue.Path.Relative(),
+		normaliseMessage(issue.Message),
+		strings.TrimSpace(f.lines.line(issue)),
+	}, "\x00")
+	f.seen[key]++
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", key, f.seen[key])))
+	return hex.EncodeToString(sum[:16])
+}
+
+func codeClimateSeverity(severity Severity) string {
+	if severity == Error {
+		return "critical"
+	}
+	return "minor"
+}
+
+func codeClimateCategory(severity Severity) string {
+	if severity == Error {
+		return "Bug Risk"
+	}
+	return "Style"
+}
+
+func newCodeClimateIssue(issue *Issue, fingerprinter *codeClimateFingerprinter) *codeClimateIssue {
+	checkName := issue.Linter
+	if rule := issue.Rule(); rule != "" {
+		checkName += "/" + rule
+	}
+	lines := codeClimateLines{Begin: issue.Line}
+	if issue.EndLine > issue.Line {
+		lines.End = issue.EndLine
+	}
+	return &codeClimateIssue{
+		Type:        "issue",
+		CheckName:   checkName,
+		Description: issue.Message,
+		Categories:  []string{codeClimateCategory(issue.Severity)},
+		Location:    codeClimateLocation{Path: issue.Path.Relative(), Lines: lines},
+		Severity:    codeClimateSeverity(issue.Severity),
+		Fingerprint: fingerprinter.fingerprint(issue),
+	}
+}
+
+func outputToCodeClimate(w io.Writer, issues chan *Issue) {
+	out := []*codeClimateIssue{}
+	fingerprinter := newCodeClimateFingerprinter()
+	for issue := range issues {
+		out = append(out, newCodeClimateIssue(issue, fingerprinter))
+	}
+	d, err := json.MarshalIndent(out, "", "  ")
+	kingpin.FatalIfError(err, "")
+	fmt.Fprintf(w, "%s\n", d)
+}
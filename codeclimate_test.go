@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeClimateIssue(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkFile(t, tmpdir, "a.go", "package foo\n\nvar _ = ioutil.ReadAll\n")
+
+	issue := &Issue{
+		Linter:   "staticcheck",
+		Severity: Warning,
+		Path:     newIssuePath(tmpdir, "a.go"),
+		Line:     3,
+		Message:  "ioutil.ReadAll is deprecated (SA1019)",
+	}
+	out := newCodeClimateIssue(issue, newCodeClimateFingerprinter())
+	data, err := json.Marshal(out)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"issue","check_name":"staticcheck/SA1019","description":"ioutil.ReadAll is deprecated (SA1019)","categories":["Style"],"location":{"path":"a.go","lines":{"begin":3}},"severity":"minor","fingerprint":"`+out.Fingerprint+`"}`, string(data))
+
+	// Fingerprints are unaffected by the issue moving down the file.
+	mkFile(t, tmpdir, "a.go", "package foo\n\nimport \"io/ioutil\"\n\nvar _ = ioutil.ReadAll\n")
+	moved := *issue
+	moved.Line = 5
+	fingerprinter := newCodeClimateFingerprinter()
+	assert.Equal(t, out.Fingerprint, fingerprinter.fingerprint(&moved))
+	// Identical issues have distinct fingerprints.
+	assert.NotEqual(t, out.Fingerprint, fingerprinter.fingerprint(&moved))
+
+	// Nor by positions quoted in the message.
+	dupl := &Issue{Linter: "dupl", Path: newIssuePath(tmpdir, "a.go"), Line: 5, Message: "5-5 lines are duplicate of b.go:19-33"}
+	original := newCodeClimateFingerprinter().fingerprint(dupl)
+	dupl.Message = "5-5 lines are duplicate of b.go:25-39"
+	assert.Equal(t, original, newCodeClimateFingerprinter().fingerprint(dupl))
+
+	issue.Severity = Error
+	issue.Linter = "vet"
+	issue.EndLine = 4
+	out = newCodeClimateIssue(issue, newCodeClimateFingerprinter())
+	assert.Equal(t, "vet/SA1019", out.CheckName)
+	assert.Equal(t, "critical", out.Severity)
+	assert.Equal(t, codeClimateLines{Begin: 3, End: 4}, out.Location.Lines)
+}
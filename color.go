@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Values for --color.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+)
+
+// useColor returns true if console output to w should be colorized. In auto
+// mode that is when w is a terminal, NO_COLOR is not set, and the default
+// --format is in use.
+func useColor(w io.Writer) bool {
+	switch config.Color {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	if config.Format != DefaultIssueFormat {
+		return false
+	}
+	return isTerminal(w)
+}
+
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// outputToText writes issues in the line format, or grouped by file with
+// colors when useColor allows.
+func outputToText(w io.Writer, issues chan *Issue) {
+	if useColor(w) {
+		outputToColorConsole(w, issues)
+	} else {
+		outputToConsole(w, issues)
+	}
+}
+
+func colorSeverity(severity Severity) string {
+	color := ansiYellow
+	if severity == Error {
+		color = ansiRed
+	}
+	return color + string(severity) + ansiReset
+}
+
+func formatPosition(line, col int) string {
+	if col == 0 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d:%d", line, col)
+}
+
+// outputToColorConsole writes issues grouped under a header for each file, in
+// the order files were first reported.
+func outputToColorConsole(w io.Writer, issues chan *Issue) {
+	paths := []string{}
+	byPath := map[string][]*Issue{}
+	for issue := range issues {
+		path := issue.Path.Relative()
+		if _, ok := byPath[path]; !ok {
+			paths = append(paths, path)
+		}
+		byPath[path] = append(byPath[path], issue)
+	}
+	for i, path := range paths {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s%s%s\n", ansiBold, path, ansiReset)
+		for _, issue := range byPath[path] {
+			fmt.Fprintf(w, "  %-8s %s  %s  %s%s%s\n",
+				formatPosition(issue.Line, issue.Col), colorSeverity(issue.Severity),
+				strings.TrimSpace(issue.Message), ansiDim, issue.Linter, ansiReset)
+			for _, location := range issue.UnmentionedRelated() {
+				fmt.Fprintf(w, "  %s         related: %s%s\n", ansiDim, location, ansiReset)
+			}
+			for _, secondary := range issue.Secondary {
+				fmt.Fprintf(w, "  %s         %s: %s (%s)%s\n", ansiDim,
+					formatPosition(secondary.Line, secondary.Col), strings.TrimSpace(secondary.Message),
+					secondary.Linter, ansiReset)
+			}
+		}
+	}
+	if config.Summary {
+		writeSummary(w, stats.finish())
+	}
+}
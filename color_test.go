@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseColor(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	originalNoColor, hadNoColor := os.LookupEnv("NO_COLOR")
+	defer func() {
+		if hadNoColor {
+			os.Setenv("NO_COLOR", originalNoColor) // nolint: errcheck
+		} else {
+			os.Unsetenv("NO_COLOR") // nolint: errcheck
+		}
+	}()
+	os.Unsetenv("NO_COLOR") // nolint: errcheck
+
+	buf := &bytes.Buffer{}
+	config.Color = colorAuto
+	assert.False(t, useColor(buf), "not a terminal")
+	config.Color = colorAlways
+	assert.True(t, useColor(buf))
+	os.Setenv("NO_COLOR", "1") // nolint: errcheck
+	assert.True(t, useColor(buf), "--color=always overrides NO_COLOR")
+	config.Color = colorNever
+	assert.False(t, useColor(buf))
+}
+
+func TestOutputToColorConsole(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.Summary = false
+
+	issues := make(chan *Issue, 3)
+	issues <- &Issue{Linter: "vet", Severity: Error, Path: newIssuePath("", "a.go"), Line: 3, Col: 2, Message: "bad"}
+	issues <- &Issue{Linter: "golint", Severity: Warning, Path: newIssuePath("", "b.go"), Line: 1, Message: "style"}
+	issues <- &Issue{Linter: "golint", Severity: Warning, Path: newIssuePath("", "a.go"), Line: 10, Message: "naming"}
+	close(issues)
+
+	buf := &bytes.Buffer{}
+	outputToColorConsole(buf, issues)
+	assert.Equal(t, "\x1b[1ma.go\x1b[0m\n"+
+		"  3:2      \x1b[31merror\x1b[0m  bad  \x1b[2mvet\x1b[0m\n"+
+		"  10       \x1b[33mwarning\x1b[0m  naming  \x1b[2mgolint\x1b[0m\n"+
+		"\n"+
+		"\x1b[1mb.go\x1b[0m\n"+
+		"  1        \x1b[33mwarning\x1b[0m  style  \x1b[2mgolint\x1b[0m\n", buf.String())
+}
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"text/template"
 	"time"
+
+	"github.com/alecthomas/units"
 )
 
 // Config for gometalinter. This can be loaded from a JSON file with --config.
@@ -48,17 +51,77 @@ type Config struct { // nolint: maligned
 	Sort            []string
 	Test            bool
 	Deadline        jsonDuration
+	MaxLinterOutput jsonBytes
 	Errors          bool
 	JSON            bool
+	NDJSON          bool
+	GitHubActions   bool
+	CodeClimate     bool
+	HTML            string
 	Checkstyle      bool
 	EnableGC        bool
 	Aggregate       bool
 	EnableAll       bool
 
+	// Colorize console output: "auto", "always" or "never"
+	Color string
+
+	// Print a summary after console output, and wrap JSON output in an
+	// object with statistics about the run
+	Summary      bool
+	JSONEnvelope bool
+
+	// Outputs in the form FORMAT[:FILE]. If empty, the output format is
+	// selected by JSON, NDJSON, GitHubActions, CodeClimate or Checkstyle.
+	Out []string
+
+	// Aggregate issues with the same "message", or at the same "position".
+	// Position aggregation groups issues starting within AggregateWindow
+	// lines of each other, with the primary issue chosen by LinterPriority.
+	AggregateBy     string
+	AggregateWindow int
+	LinterPriority  []string
+
+	// Report at most this many issues from each linter, and this many issues
+	// from a linter with the same message once quotes and numbers are
+	// ignored. 0 disables the limit.
+	MaxIssuesPerLinter int
+	MaxSameIssues      int
+
+	// Exclude issues matching any of these rules, and with ExcludeRulesReport
+	// print the number of issues excluded by each rule
+	ExcludeRules       []*ExcludeRule
+	ExcludeRulesReport bool
+
 	// Warn if a nolint directive was never matched to a linter issue
 	WarnUnmatchedDirective bool
 
-	formatTemplate *template.Template
+	// Remove linters that were never matched to an issue from nolint
+	// directives, and directives with no linters left, from source files
+	FixUnmatchedDirective bool
+
+	// Report nolint directives without a reason, or that do not name a linter
+	RequireNolintReason   bool
+	RequireNolintSpecific bool
+
+	// Write a report of all nolint directives to this file ("-" for stdout)
+	// in "text" or "json" format
+	NolintReport       string
+	NolintReportFormat string
+
+	// Stop all linters as soon as the first issue or linter error is reported
+	FailFast bool
+
+	// Report issues in generated files. Files are generated if they have a
+	// "// Code generated ... DO NOT EDIT." header, a header matching one of
+	// the GeneratedHeaders regular expressions, or a path matching one of the
+	// GeneratedPaths globs.
+	IncludeGenerated bool
+	GeneratedHeaders []string
+	GeneratedPaths   []string
+
+	formatTemplate   *template.Template
+	generatedHeaders []*regexp.Regexp
 }
 
 type StringOrLinterConfig LinterConfig
@@ -102,6 +165,27 @@ func (td *jsonDuration) Duration() time.Duration {
 	return time.Duration(*td)
 }
 
+type jsonBytes units.Base2Bytes
+
+func (b *jsonBytes) UnmarshalJSON(raw []byte) error {
+	var n int64
+	if err := json.Unmarshal(raw, &n); err == nil {
+		*b = jsonBytes(n)
+		return nil
+	}
+	var bytesAsString string
+	if err := json.Unmarshal(raw, &bytesAsString); err != nil {
+		return err
+	}
+	size, err := units.ParseBase2Bytes(bytesAsString)
+	*b = jsonBytes(size)
+	return err
+}
+
+func (b jsonBytes) String() string {
+	return units.Base2Bytes(b).String()
+}
+
 var sortKeys = []string{"none", "path", "line", "column", "severity", "message", "linter"}
 
 // Configuration defaults.
@@ -138,6 +222,10 @@ var config = &Config{
 	DuplThreshold:   50,
 	Sort:            []string{"none"},
 	Deadline:        jsonDuration(time.Second * 30),
+
+	NolintReportFormat: "text",
+	AggregateBy:        aggregateByMessage,
+	Color:              colorAuto,
 }
 
 func loadConfigFile(filename string) error {
@@ -80,3 +80,11 @@ func TestFindDefaultConfigFile(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+
+func TestJSONBytesUnmarshalJSON(t *testing.T) {
+	var actual struct{ Size jsonBytes }
+	require.NoError(t, json.Unmarshal([]byte(`{"Size": "2KB"}`), &actual))
+	assert.Equal(t, jsonBytes(2048), actual.Size)
+	require.NoError(t, json.Unmarshal([]byte(`{"Size": 100}`), &actual))
+	assert.Equal(t, jsonBytes(100), actual.Size)
+}
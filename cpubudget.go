@@ -0,0 +1,98 @@
+package main
+
+import "sync"
+
+// cpuBudget hands out GOMAXPROCS values to linters as they start. Each of the
+// total concurrency slots is worth one CPU, and the CPUs not in use by running
+// linters are shared between the linters that could start now. CPUs reserved
+// for other work are not counted against linters.
+type cpuBudget struct {
+	lock sync.Mutex
+	// freed is signalled whenever CPUs are returned to the budget.
+	freed    *sync.Cond
+	total    int
+	used     int
+	reserved int
+	running  int
+	pending  int
+}
+
+func newCPUBudget(total int) *cpuBudget {
+	b := &cpuBudget{total: total}
+	b.freed = sync.NewCond(&b.lock)
+	return b
+}
+
+// queue records that n more linters are waiting to start.
+func (b *cpuBudget) queue(n int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.pending += n
+}
+
+// acquire returns the GOMAXPROCS value for a linter that is about to start. If
+// fixed is greater than zero it is used instead of the allocated value.
+func (b *cpuBudget) acquire(fixed int) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	startable := b.total - b.running
+	if b.pending < startable {
+		startable = b.pending
+	}
+	if startable < 1 {
+		startable = 1
+	}
+	n := fixed
+	if n <= 0 {
+		n = (b.total - b.used) / startable
+	}
+	if n < 1 {
+		n = 1
+	}
+	if b.pending > 0 {
+		b.pending--
+	}
+	b.used += n
+	b.running++
+	return n
+}
+
+// release returns CPUs previously handed out by acquire.
+func (b *cpuBudget) release(n int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.used -= n
+	b.running--
+	b.freed.Broadcast()
+}
+
+// reserve blocks until a CPU is free and allocates it to work other than a
+// linter, such as parsing directives. Linters are allocated CPUs as if no
+// work was reserved and are never blocked, so such work only uses CPUs that
+// linters leave free.
+func (b *cpuBudget) reserve() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for b.used+b.reserved >= b.total {
+		b.freed.Wait()
+	}
+	b.reserved++
+}
+
+// unreserve returns a CPU allocated by reserve.
+func (b *cpuBudget) unreserve() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.reserved--
+	b.freed.Broadcast()
+}
+
+// free returns the number of CPUs not currently allocated.
+func (b *cpuBudget) free() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if free := b.total - b.used - b.reserved; free > 0 {
+		return free
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCPUBudgetSingleLinter(t *testing.T) {
+	budget := newCPUBudget(8)
+	budget.queue(1)
+	assert.Equal(t, 8, budget.acquire(0))
+	assert.Equal(t, 0, budget.free())
+	budget.release(8)
+	assert.Equal(t, 8, budget.free())
+}
+
+func TestCPUBudgetSharedBetweenStartable(t *testing.T) {
+	budget := newCPUBudget(8)
+	budget.queue(3)
+	first := budget.acquire(0)
+	second := budget.acquire(0)
+	third := budget.acquire(0)
+	assert.Equal(t, []int{2, 3, 3}, []int{first, second, third})
+	assert.Equal(t, 0, budget.free())
+}
+
+func TestCPUBudgetManyLinters(t *testing.T) {
+	budget := newCPUBudget(4)
+	budget.queue(100)
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, 1, budget.acquire(0))
+	}
+	// Slots are exhausted, but a linter always gets at least one CPU.
+	assert.Equal(t, 1, budget.acquire(0))
+}
+
+func TestCPUBudgetFixed(t *testing.T) {
+	budget := newCPUBudget(4)
+	budget.queue(2)
+	assert.Equal(t, 1, budget.acquire(1))
+	assert.Equal(t, 3, budget.acquire(0))
+}
+
+func TestCPUBudgetReserveWaitsForFreeCPU(t *testing.T) {
+	budget := newCPUBudget(2)
+	budget.queue(1)
+	assert.Equal(t, 2, budget.acquire(0))
+
+	reserved := make(chan struct{})
+	go func() {
+		budget.reserve()
+		close(reserved)
+	}()
+	select {
+	case <-reserved:
+		t.Fatal("reserved a CPU while none were free")
+	case <-time.After(50 * time.Millisecond):
+	}
+	budget.release(2)
+	<-reserved
+	assert.Equal(t, 1, budget.free())
+	budget.unreserve()
+	assert.Equal(t, 2, budget.free())
+}
+
+func TestCPUBudgetReservedCPUsNotCountedAgainstLinters(t *testing.T) {
+	budget := newCPUBudget(4)
+	for i := 0; i < 4; i++ {
+		budget.reserve()
+	}
+	budget.queue(1)
+	assert.Equal(t, 4, budget.acquire(0))
+	assert.Equal(t, 0, budget.free())
+
+	// Reserved CPUs are not handed out again until linters release theirs.
+	for i := 0; i < 4; i++ {
+		budget.unreserve()
+	}
+	reserved := make(chan struct{})
+	go func() {
+		budget.reserve()
+		close(reserved)
+	}()
+	select {
+	case <-reserved:
+		t.Fatal("reserved a CPU allocated to a linter")
+	case <-time.After(50 * time.Millisecond):
+	}
+	budget.release(4)
+	<-reserved
+	assert.Equal(t, 3, budget.free())
+}
@@ -5,7 +5,11 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
@@ -17,17 +21,57 @@ type ignoredRange struct {
 	start, end int
 	linters    []string
 	matched    bool
+	// Linters for which the directive suppressed at least one issue.
+	matchedLinters *stringSet
+	// Syntax of the directive, eg. "nolint" or "lint:ignore".
+	syntax string
+	// Original comment text and its byte offsets in the file.
+	text                   string
+	startOffset, endOffset int
+	// Check IDs that must appear in an issue's message for it to match, eg.
+	// "SA1019". Used by staticcheck directives.
+	checks []string
+	// Line of the directive itself, start may be extended by rangeExpander.
+	line int
+	// Explanation following the directive, eg. "// nolint: errcheck // reason"
+	reason string
+	// Date given by "until=YYYY-MM-DD", after which the directive no longer
+	// suppresses issues. invalidUntil holds an unparseable date.
+	until        string
+	invalidUntil string
+	expired      bool
+	// The directive applies to the whole file, or every file in the package
+	// directory.
+	fileWide    bool
+	packageWide bool
 }
 
 func (i *ignoredRange) matches(issue *Issue) bool {
-	if issue.Line < i.start || issue.Line > i.end {
+	if i.expired {
+		return false
+	}
+	if !i.packageWide && (issue.LastLine() < i.start || issue.Line > i.end) {
 		return false
 	}
 	if len(i.linters) == 0 {
 		return true
 	}
 	for _, l := range i.linters {
-		if l == issue.Linter {
+		for _, linter := range issue.LinterNames() {
+			if l == linter {
+				return i.matchesCheck(issue)
+			}
+		}
+	}
+	return false
+}
+
+func (i *ignoredRange) matchesCheck(issue *Issue) bool {
+	if len(i.checks) == 0 {
+		return true
+	}
+	for _, check := range i.checks {
+		if check == "*" || strings.Contains(issue.Message, "("+check+")") {
 			return true
 		}
 	}
@@ -43,6 +87,12 @@ func (i *ignoredRange) String() string {
 	if len(i.linters) == 0 {
 		linters = "all"
 	}
+	if len(i.checks) > 0 {
+		linters += "(" + strings.Join(i.checks, ",") + ")"
+	}
+	if i.packageWide {
+		return fmt.Sprintf("%s:package", linters)
+	}
 	return fmt.Sprintf("%s:%d-%d", linters, i.start, i.end)
 }
 
@@ -53,45 +103,147 @@ func (ir ignoredRanges) Swap(i, j int)      { ir[i], ir[j] = ir[j], ir[i] }
 func (ir ignoredRanges) Less(i, j int) bool { return ir[i].end < ir[j].end }
 
 type directiveParser struct {
-	lock  sync.Mutex
-	files map[string]ignoredRanges
-	fset  *token.FileSet
+	// lock guards the maps below. It is not held while parsing, so files can
+	// be parsed concurrently.
+	lock      sync.Mutex
+	files     map[string]ignoredRanges
+	generated map[string]bool
+	// Files currently being parsed, closed when parsing is complete.
+	loading map[string]chan struct{}
+	fset    *token.FileSet
+	// Background loading started by Prewarm.
+	prewarm sync.WaitGroup
+	// Filtering by filterIssuesViaDirectives, which reports on the directives
+	// once all issues have been filtered.
+	filtering sync.WaitGroup
+	// Total time spent parsing and the number of files parsed.
+	parseTime   time.Duration
+	parsedFiles int
+	// Linters that ran to completion. Only set once all linters have exited.
+	lintersRun *stringSet
 }
 
 func newDirectiveParser() *directiveParser {
 	return &directiveParser{
-		files: map[string]ignoredRanges{},
-		fset:  token.NewFileSet(),
+		files:     map[string]ignoredRanges{},
+		generated: map[string]bool{},
+		loading:   map[string]chan struct{}{},
+		fset:      token.NewFileSet(),
 	}
 }
 
+// nolintFilename is the name of a file listing directives that apply to every
+// file in its directory, one per line.
+const nolintFilename = ".nolint"
+
 // IsIgnored returns true if the given linter issue is ignored by a linter directive.
 func (d *directiveParser) IsIgnored(issue *Issue) bool {
-	d.lock.Lock()
 	path := issue.Path.Relative()
-	ranges, ok := d.files[path]
-	if !ok {
-		ranges = d.parseFile(path)
-		sort.Sort(ranges)
-		d.files[path] = ranges
+	ranges := d.load(path)
+	// Package-wide directives come from doc.go or a .nolint file.
+	for _, source := range packageDirectiveSources(filepath.Dir(path)) {
+		if source == path {
+			continue
+		}
+		for _, r := range d.load(source) {
+			if r.packageWide {
+				ranges = append(ranges[:len(ranges):len(ranges)], r)
+			}
+		}
 	}
-	d.lock.Unlock()
 	for _, r := range ranges {
 		if r.matches(issue) {
 			debug("nolint: matched %s to issue %s", r, issue)
+			d.lock.Lock()
 			r.matched = true
+			if r.matchedLinters == nil {
+				r.matchedLinters = newStringSet()
+			}
+			for _, linter := range issue.LinterNames() {
+				r.matchedLinters.add(linter)
+			}
+			d.lock.Unlock()
 			return true
 		}
 	}
 	return false
 }
 
+// Observe records that the directives covering an issue matched it, without
+// filtering the issue. Issues that are dropped before reaching IsIgnored, such
+// as those excluded by --exclude or in generated files, are observed so that
+// their directives are not reported or removed as unmatched.
+func (d *directiveParser) Observe(issue *Issue) {
+	d.IsIgnored(issue)
+}
+
+// IsGenerated returns true if the file an issue was reported in is generated,
+// either because it matches one of the configured generated paths or because
+// it has a generated code header.
+func (d *directiveParser) IsGenerated(issue *Issue) bool {
+	return d.isGeneratedPath(issue.Path.Relative())
+}
+
+func (d *directiveParser) isGeneratedPath(path string) bool {
+	if matchesGeneratedPath(path) {
+		return true
+	}
+	d.load(path)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.generated[path]
+}
+
+// load returns the directives in path, parsing the file if necessary. If
+// another goroutine is already parsing the file, load waits for it to finish.
+func (d *directiveParser) load(path string) ignoredRanges {
+	d.lock.Lock()
+	if ranges, ok := d.files[path]; ok {
+		d.lock.Unlock()
+		return ranges
+	}
+	if done, ok := d.loading[path]; ok {
+		d.lock.Unlock()
+		<-done
+		d.lock.Lock()
+		defer d.lock.Unlock()
+		return d.files[path]
+	}
+	done := make(chan struct{})
+	d.loading[path] = done
+	d.lock.Unlock()
+
+	start := time.Now()
+	var ranges ignoredRanges
+	generated := false
+	if filepath.Base(path) == nolintFilename {
+		ranges = d.parseNolintFile(path)
+	} else {
+		ranges, generated = d.parseFile(path)
+	}
+	sort.Sort(ranges)
+
+	d.lock.Lock()
+	d.files[path] = ranges
+	d.generated[path] = generated
+	d.parseTime += time.Since(start)
+	d.parsedFiles++
+	delete(d.loading, path)
+	d.lock.Unlock()
+	close(done)
+	return ranges
+}
+
+func packageDirectiveSources(dir string) []string {
+	return []string{filepath.Join(dir, "doc.go"), filepath.Join(dir, nolintFilename)}
+}
+
 // Unmatched returns all the ranges which were never used to ignore an issue
 func (d *directiveParser) Unmatched() map[string]ignoredRanges {
 	unmatched := map[string]ignoredRanges{}
 	for path, ranges := range d.files {
 		for _, ignore := range ranges {
-			if !ignore.matched {
+			if !ignore.matched && !ignore.expired {
 				unmatched[path] = append(unmatched[path], ignore)
 			}
 		}
@@ -99,22 +251,79 @@ func (d *directiveParser) Unmatched() map[string]ignoredRanges {
 	return unmatched
 }
 
-// LoadFiles from a list of directories
+// LoadFiles from a list of directories, parsing files concurrently.
 func (d *directiveParser) LoadFiles(paths []string) error {
-	d.lock.Lock()
-	defer d.lock.Unlock()
+	return d.loadFiles(paths, newCPUBudget(runtime.NumCPU()))
+}
+
+// loadFiles parses files on as many CPUs as are free in budget.
+func (d *directiveParser) loadFiles(paths []string, budget *cpuBudget) error {
+	start := time.Now()
 	filenames, err := pathsToFileGlobs(paths)
 	if err != nil {
 		return err
 	}
+	for _, path := range paths {
+		filenames = append(filenames, filepath.Join(path, nolintFilename))
+	}
+	filenamech := make(chan string)
+	wg := sync.WaitGroup{}
+	for i := 0; i < budget.total; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filename := range filenamech {
+				budget.reserve()
+				d.load(filename)
+				budget.unreserve()
+			}
+		}()
+	}
 	for _, filename := range filenames {
-		ranges := d.parseFile(filename)
-		sort.Sort(ranges)
-		d.files[filename] = ranges
+		filenamech <- filename
 	}
+	close(filenamech)
+	wg.Wait()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	debug("nolint: loading directives from %d files took %s (%s parsing %d files in total)",
+		len(filenames), time.Since(start), d.parseTime, d.parsedFiles)
 	return nil
 }
 
+// Prewarm starts loading directives from a list of directories in the
+// background, using CPUs left free by linters. Wait blocks until it has
+// completed.
+func (d *directiveParser) Prewarm(paths []string, budget *cpuBudget) {
+	d.prewarm.Add(1)
+	go func() {
+		defer d.prewarm.Done()
+		if err := d.loadFiles(paths, budget); err != nil {
+			warning("failed to load nolint directives: %s", err)
+		}
+	}()
+}
+
+// Wait for directives started by Prewarm to finish loading.
+func (d *directiveParser) Wait() {
+	d.prewarm.Wait()
+}
+
+// WaitFiltered waits for filterIssuesViaDirectives to finish, including any
+// reports on the directives. Its output channel may be abandoned before then.
+func (d *directiveParser) WaitFiltered() {
+	d.filtering.Wait()
+}
+
+// parseTiming returns the number of files parsed and the total time spent
+// parsing them.
+func (d *directiveParser) parseTiming() (int, time.Duration) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.parsedFiles, d.parseTime
+}
+
 // Takes a set of ignoredRanges, determines if they immediately precede a statement
 // construct, and expands the range to include that construct. Why? So you can
 // precede a function or struct with //nolint
@@ -133,7 +342,7 @@ func (a *rangeExpander) Visit(node ast.Node) ast.Visitor {
 	found := sort.Search(len(a.ranges), func(i int) bool {
 		return a.ranges[i].end+1 >= start
 	})
-	if found < len(a.ranges) && a.ranges[found].near(startPos.Column, start) {
+	if found < len(a.ranges) && a.ranges[found].syntax != staticcheckIgnoreSyntax && a.ranges[found].near(startPos.Column, start) {
 		r := a.ranges[found]
 		if r.start > start {
 			r.start = start
@@ -145,41 +354,254 @@ func (a *rangeExpander) Visit(node ast.Node) ast.Visitor {
 	return a
 }
 
-func (d *directiveParser) parseFile(path string) ignoredRanges {
+// parseFile returns the directives in a Go source file, and whether the file
+// is generated.
+func (d *directiveParser) parseFile(path string) (ignoredRanges, bool) {
 	start := time.Now()
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	// Without any directives there are no ranges to expand, so only the
+	// header is needed to check whether the file is generated.
+	if !directiveMarkerRegexp.Match(source) {
+		file, err := parser.ParseFile(d.fset, path, source, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			debug("nolint: failed to parse %q: %s", path, err)
+			return nil, false
+		}
+		debug("nolint: scanning %s header took %s", path, time.Since(start))
+		return nil, hasGeneratedHeader(file)
+	}
 	debug("nolint: parsing %s for directives", path)
-	file, err := parser.ParseFile(d.fset, path, nil, parser.ParseComments)
+	file, err := parser.ParseFile(d.fset, path, source, parser.ParseComments)
 	if err != nil {
 		debug("nolint: failed to parse %q: %s", path, err)
-		return nil
+		return nil, false
 	}
 	ranges := extractCommentGroupRange(d.fset, file.Comments...)
 	visitor := &rangeExpander{fset: d.fset, ranges: ranges}
 	ast.Walk(visitor, file)
+
+	// nolint directives before the package clause cover the whole file, or in
+	// doc.go the whole package.
+	packageLine := d.fset.Position(file.Package).Line
+	lastLine := d.fset.File(file.Pos()).LineCount()
+	for _, r := range visitor.ranges {
+		if r.syntax == nolintSyntax && r.line < packageLine {
+			r.fileWide = true
+			r.packageWide = filepath.Base(path) == "doc.go"
+		}
+		if r.fileWide {
+			r.start = 1
+			r.end = lastLine
+		}
+	}
+	generated := hasGeneratedHeader(file)
+	if generated {
+		debug("nolint: %s is generated", path)
+	}
 	debug("nolint: parsing %s took %s", path, time.Since(start))
-	return visitor.ranges
+	return visitor.ranges, generated
+}
+
+// directiveMarkerRegexp matches text that may be a directive of one of the
+// directiveSyntaxes.
+var directiveMarkerRegexp = regexp.MustCompile(`nolint|lint:`)
+
+// generatedHeaderRegexp matches the standard header of generated Go files, see
+// https://golang.org/s/generatedcode.
+var generatedHeaderRegexp = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// hasGeneratedHeader returns true if a comment before the package clause
+// marks the file as generated.
+func hasGeneratedHeader(file *ast.File) bool {
+	patterns := append([]*regexp.Regexp{generatedHeaderRegexp}, config.generatedHeaders...)
+	for _, g := range file.Comments {
+		if g.Pos() > file.Package {
+			break
+		}
+		for _, c := range g.List {
+			for _, pattern := range patterns {
+				if pattern.MatchString(c.Text) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// matchesGeneratedPath returns true if path, or its base name, matches one of
+// the configured generated path globs.
+func matchesGeneratedPath(path string) bool {
+	for _, glob := range config.GeneratedPaths {
+		if matchesPathGlob(glob, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPathGlob returns true if glob matches path, or the base name of path.
+func matchesPathGlob(glob, path string) bool {
+	if ok, _ := filepath.Match(glob, path); ok {
+		return true
+	}
+	ok, _ := filepath.Match(glob, filepath.Base(path))
+	return ok
+}
+
+// parseNolintFile parses a .nolint file, each line of which is a directive
+// covering the package in the same directory.
+func (d *directiveParser) parseNolintFile(path string) (ranges ignoredRanges) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			warning("failed to read %s: %s", path, err)
+		}
+		return nil
+	}
+	debug("nolint: parsing %s for directives", path)
+	now := time.Now()
+	for i, line := range strings.Split(string(data), "\n") {
+		text := strings.TrimSpace(line)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		rng := parseNolintDirective(text, now)
+		if rng == nil {
+			warning("%s:%d: invalid nolint directive %q", path, i+1, text)
+			continue
+		}
+		rng.line = i + 1
+		rng.col = 1
+		rng.packageWide = true
+		ranges = append(ranges, rng)
+	}
+	return ranges
+}
+
+// nolintUntilDate is the format of the date in "until=" directive options.
+const nolintUntilDate = "2006-01-02"
+
+var nolintUntilRegexp = regexp.MustCompile(`\buntil=(\S*)`)
+
+// parseUntil extracts an "until=YYYY-MM-DD" option from a directive, returning
+// the directive without it.
+func parseUntil(text string, rng *ignoredRange, now time.Time) string {
+	match := nolintUntilRegexp.FindStringSubmatchIndex(text)
+	if match == nil {
+		return text
+	}
+	value := text[match[2]:match[3]]
+	text = text[:match[0]] + text[match[1]:]
+	until, err := time.ParseInLocation(nolintUntilDate, value, time.Local)
+	if err != nil {
+		rng.invalidUntil = value
+		return text
+	}
+	rng.until = value
+	rng.expired = !now.Before(until.AddDate(0, 0, 1))
+	return text
+}
+
+// A directiveSyntax parses one form of suppression comment, returning nil if
+// the comment text is not a directive of that form. Only the syntax, linters,
+// checks, expiry, reason and scope of the returned range are set.
+type directiveSyntax func(text string, now time.Time) *ignoredRange
+
+// Directive syntaxes.
+const (
+	nolintSyntax                = "nolint"
+	staticcheckIgnoreSyntax     = "lint:ignore"
+	staticcheckFileIgnoreSyntax = "lint:file-ignore"
+)
+
+var directiveSyntaxes = []directiveSyntax{
+	parseNolintDirective,
+	parseStaticcheckDirective,
+}
+
+// parseNolintDirective parses "// nolint[: linter, ...] [until=DATE] [// reason]".
+// The space after the comment marker is optional.
+func parseNolintDirective(text string, now time.Time) *ignoredRange {
+	text = strings.TrimLeft(text, "/ ")
+	if !strings.HasPrefix(text, "nolint") {
+		return nil
+	}
+	rng := &ignoredRange{syntax: nolintSyntax}
+	if i := strings.Index(text, "//"); i >= 0 {
+		text, rng.reason = text[:i], strings.TrimSpace(text[i+2:])
+	}
+	text = parseUntil(text, rng, now)
+	if strings.HasPrefix(text, "nolint:") {
+		for _, linter := range strings.Split(text[7:], ",") {
+			if linter = strings.TrimSpace(linter); linter != "" {
+				rng.linters = append(rng.linters, linter)
+			}
+		}
+	}
+	return rng
+}
+
+// Linters that report staticcheck check IDs.
+var staticcheckLinters = []string{"staticcheck", "gosimple", "unused", "megacheck"}
+
+// parseStaticcheckDirective parses staticcheck's "//lint:ignore CHECK[,...] reason"
+// and "//lint:file-ignore CHECK[,...] reason" directives.
+func parseStaticcheckDirective(text string, now time.Time) *ignoredRange {
+	if !strings.HasPrefix(text, "//lint:") {
+		return nil
+	}
+	fields := strings.Fields(text[2:])
+	syntax := fields[0]
+	if (syntax != staticcheckIgnoreSyntax && syntax != staticcheckFileIgnoreSyntax) || len(fields) < 2 {
+		return nil
+	}
+	return &ignoredRange{
+		syntax:   syntax,
+		linters:  staticcheckLinters,
+		checks:   strings.Split(fields[1], ","),
+		reason:   strings.Join(fields[2:], " "),
+		fileWide: syntax == staticcheckFileIgnoreSyntax,
+	}
+}
+
+// parseDirective parses a comment using each of the directiveSyntaxes in turn.
+func parseDirective(text string, now time.Time) *ignoredRange {
+	for _, parse := range directiveSyntaxes {
+		if rng := parse(text, now); rng != nil {
+			return rng
+		}
+	}
+	return nil
 }
 
 func extractCommentGroupRange(fset *token.FileSet, comments ...*ast.CommentGroup) (ranges ignoredRanges) {
+	now := time.Now()
 	for _, g := range comments {
 		for _, c := range g.List {
-			text := strings.TrimLeft(c.Text, "/ ")
-			var linters []string
-			if strings.HasPrefix(text, "nolint") {
-				if strings.HasPrefix(text, "nolint:") {
-					for _, linter := range strings.Split(text[7:], ",") {
-						linters = append(linters, strings.TrimSpace(linter))
-					}
-				}
-				pos := fset.Position(g.Pos())
-				rng := &ignoredRange{
-					col:     pos.Column,
-					start:   pos.Line,
-					end:     fset.Position(g.End()).Line,
-					linters: linters,
-				}
-				ranges = append(ranges, rng)
+			rng := parseDirective(c.Text, now)
+			if rng == nil {
+				continue
 			}
+			// The range covers the comment group, but the directive is
+			// reported at the position of its own comment.
+			pos := fset.Position(c.Pos())
+			rng.col = pos.Column
+			rng.line = pos.Line
+			rng.start = fset.Position(g.Pos()).Line
+			rng.end = fset.Position(g.End()).Line
+			if rng.syntax == staticcheckIgnoreSyntax {
+				// staticcheck only ignores the line following the directive.
+				rng.start = rng.line
+				rng.end = rng.line + 1
+			}
+			rng.text = c.Text
+			rng.startOffset = pos.Offset
+			rng.endOffset = fset.Position(c.End()).Offset
+			ranges = append(ranges, rng)
 		}
 	}
 	return
@@ -187,23 +609,115 @@ func extractCommentGroupRange(fset *token.FileSet, comments ...*ast.CommentGroup
 
 func filterIssuesViaDirectives(directives *directiveParser, issues chan *Issue) chan *Issue {
 	out := make(chan *Issue, 1000000)
+	directives.filtering.Add(1)
 	go func() {
+		defer directives.filtering.Done()
 		for issue := range issues {
-			if !directives.IsIgnored(issue) {
-				out <- issue
+			if !config.IncludeGenerated && directives.IsGenerated(issue) {
+				stats.suppressed(suppressedByGenerated, 1)
+				continue
 			}
+			if directives.IsIgnored(issue) {
+				stats.suppressed(suppressedByNolint, 1)
+				continue
+			}
+			out <- issue
 		}
+		directives.Wait()
 
 		if config.WarnUnmatchedDirective {
 			for _, issue := range warnOnUnusedDirective(directives) {
 				out <- issue
 			}
 		}
+		for _, issue := range lintDirectives(directives) {
+			out <- issue
+		}
+		if config.FixUnmatchedDirective {
+			fixUnmatchedDirectives(directives)
+		}
+		if config.NolintReport != "" {
+			if err := writeNolintReport(directives, config.NolintReport, config.NolintReportFormat); err != nil {
+				warning("failed to write nolint report: %s", err)
+			}
+		}
+		stats.directivesParsed(directives.parseTiming())
 		close(out)
 	}()
 	return out
 }
 
+// loadAllDirectives returns true if directives must be parsed from every file
+// being linted, not just those with issues.
+func loadAllDirectives() bool {
+	return reportsDirectiveMatches() || config.RequireNolintReason || config.RequireNolintSpecific
+}
+
+// reportsDirectiveMatches returns true if whether directives matched an issue
+// is reported or acted on.
+func reportsDirectiveMatches() bool {
+	return config.WarnUnmatchedDirective || config.FixUnmatchedDirective || config.NolintReport != ""
+}
+
+// lintDirectives returns issues for expired directives, directives with an
+// invalid expiry date, and directives that do not meet the requirements set by
+// --require-nolint-reason and --require-nolint-specific.
+func lintDirectives(directives *directiveParser) []*Issue {
+	out := []*Issue{}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		warning("failed to get working directory %s", err)
+	}
+
+	for path, ranges := range directives.files {
+		for _, ignore := range ranges {
+			var messages []string
+			if ignore.expired {
+				messages = append(messages, fmt.Sprintf("nolint directive expired on %s", ignore.until))
+			}
+			if ignore.invalidUntil != "" {
+				messages = append(messages, fmt.Sprintf("nolint directive has invalid expiry date %q (expected YYYY-MM-DD)", ignore.invalidUntil))
+			}
+			if ignore.syntax == nolintSyntax {
+				messages = append(messages, unknownDirectiveLinters(ignore)...)
+			}
+			if config.RequireNolintSpecific && len(ignore.linters) == 0 {
+				messages = append(messages, "nolint directive does not name any linters")
+			}
+			if config.RequireNolintReason && ignore.reason == "" {
+				messages = append(messages, "nolint directive has no reason")
+			}
+			for _, message := range messages {
+				issue, _ := NewIssue("nolint", config.formatTemplate)
+				issue.Path = newIssuePath(cwd, path)
+				issue.Line = ignore.line
+				issue.Col = ignore.col
+				issue.Message = message
+				out = append(out, issue)
+			}
+		}
+	}
+	return out
+}
+
+// unknownDirectiveLinters returns a message for each linter named by a
+// directive that is neither a default nor a custom linter.
+func unknownDirectiveLinters(ignore *ignoredRange) []string {
+	messages := []string{}
+	for _, linter := range ignore.linters {
+		if linter == "nolint" || isKnownLinter(linter) {
+			continue
+		}
+		message := fmt.Sprintf("nolint directive names unknown linter %q", linter)
+		if suggestion := suggestLinter(linter); suggestion != "" {
+			message += fmt.Sprintf(", did you mean %q?", suggestion)
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
 func warnOnUnusedDirective(directives *directiveParser) []*Issue {
 	out := []*Issue{}
 
@@ -216,9 +730,9 @@ func warnOnUnusedDirective(directives *directiveParser) []*Issue {
 		for _, ignore := range ranges {
 			issue, _ := NewIssue("nolint", config.formatTemplate)
 			issue.Path = newIssuePath(cwd, path)
-			issue.Line = ignore.start
+			issue.Line = ignore.line
 			issue.Col = ignore.col
-			issue.Message = "nolint directive did not match any issue"
+			issue.Message = fmt.Sprintf("%s directive did not match any issue", ignore.syntax)
 			out = append(out, issue)
 		}
 	}
@@ -1,9 +1,19 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sync"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIgnoreRangeMatch(t *testing.T) {
@@ -33,6 +43,11 @@ func TestIgnoreRangeMatch(t *testing.T) {
 			linters:  []string{"vet"},
 			expected: true,
 		},
+		{
+			doc:      "issue range overlaps directive range",
+			issue:    Issue{Line: 2, EndLine: 6},
+			expected: true,
+		},
 	}
 
 	for _, testcase := range testcases {
@@ -40,3 +55,325 @@ func TestIgnoreRangeMatch(t *testing.T) {
 		assert.Equal(t, testcase.expected, ir.matches(&testcase.issue), testcase.doc)
 	}
 }
+
+func TestExtractCommentGroupRangeReason(t *testing.T) {
+	source := `package foo
+
+func a() {
+	a := 10 // nolint:errcheck, golint // closing a read-only file
+	b := 10 // nolint
+	c := 10 // nolint: vet
+	_ = 10  // nolint // generated
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", source, parser.ParseComments)
+	require.NoError(t, err)
+	ranges := extractCommentGroupRange(fset, file.Comments...)
+	require.Len(t, ranges, 4)
+
+	assert.Equal(t, []string{"errcheck", "golint"}, ranges[0].linters)
+	assert.Equal(t, "closing a read-only file", ranges[0].reason)
+	assert.Equal(t, 4, ranges[0].line)
+	assert.Nil(t, ranges[1].linters)
+	assert.Equal(t, "", ranges[1].reason)
+	assert.Equal(t, []string{"vet"}, ranges[2].linters)
+	assert.Nil(t, ranges[3].linters)
+	assert.Equal(t, "generated", ranges[3].reason)
+}
+
+func TestExtractCommentGroupRangePosition(t *testing.T) {
+	source := `package foo
+
+func a() {
+	// first
+		// nolint: vet
+	_ = 10
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", source, parser.ParseComments)
+	require.NoError(t, err)
+	ranges := extractCommentGroupRange(fset, file.Comments...)
+	require.Len(t, ranges, 1)
+	assert.Equal(t, 5, ranges[0].line)
+	assert.Equal(t, 3, ranges[0].col)
+	assert.Equal(t, 4, ranges[0].start)
+	assert.Equal(t, 5, ranges[0].end)
+}
+
+func TestLintDirectives(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.RequireNolintReason = true
+	config.RequireNolintSpecific = true
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+
+	directives := newDirectiveParser()
+	directives.files["foo.go"] = ignoredRanges{
+		{line: 1, linters: []string{"vet"}, reason: "false positive"},
+		{line: 2, linters: []string{"vet"}},
+		{line: 3, reason: "generated"},
+	}
+	issues := lintDirectives(directives)
+	messages := []string{}
+	for _, issue := range issues {
+		assert.Equal(t, "nolint", issue.Linter)
+		messages = append(messages, fmt.Sprintf("%d: %s", issue.Line, issue.Message))
+	}
+	assert.Equal(t, []string{
+		"2: nolint directive has no reason",
+		"3: nolint directive does not name any linters",
+	}, messages)
+}
+
+func TestNolintReport(t *testing.T) {
+	directives := newDirectiveParser()
+	directives.files["b.go"] = ignoredRanges{
+		{line: 7, col: 2, syntax: nolintSyntax, linters: []string{"vet"}, reason: "false positive", matched: true},
+	}
+	directives.files["a.go"] = ignoredRanges{
+		{line: 3, col: 1, syntax: nolintSyntax},
+		{line: 5, col: 1, syntax: staticcheckIgnoreSyntax, linters: staticcheckLinters, checks: []string{"SA1019"}, reason: "needed"},
+	}
+	report := nolintReport(directives)
+	require.Len(t, report, 3)
+	assert.Equal(t, "a.go:3:1: nolint:all (no reason) [unmatched]", report[0].String())
+	assert.Equal(t, "a.go:5:1: lint:ignore:staticcheck,gosimple,unused,megacheck(SA1019) needed [unmatched]", report[1].String())
+	assert.Equal(t, "b.go:7:2: nolint:vet false positive [matched]", report[2].String())
+
+	data, err := json.Marshal(report[0])
+	require.NoError(t, err)
+	assert.Equal(t, `{"path":"a.go","line":3,"col":1,"syntax":"nolint","linters":[],"reason":"","matched":false}`, string(data))
+}
+
+func TestWriteNolintReportToStdoutIsHeldBack(t *testing.T) {
+	defer stdoutNolintReport.Reset()
+	directives := newDirectiveParser()
+	directives.files["a.go"] = ignoredRanges{{line: 3, col: 1, syntax: nolintSyntax}}
+
+	require.NoError(t, writeNolintReport(directives, "-", "text"))
+	assert.Equal(t, "a.go:3:1: nolint:all (no reason) [unmatched]\n", stdoutNolintReport.String())
+}
+
+func TestExtractCommentGroupRangeUntil(t *testing.T) {
+	source := `package foo
+
+func a() {
+	a := 10 // nolint:gocyclo until=2000-12-31 // refactor tracked in backlog
+	b := 10 // nolint:vet until=2999-01-01
+	c := 10 // nolint until=tomorrow
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "foo.go", source, parser.ParseComments)
+	require.NoError(t, err)
+	ranges := extractCommentGroupRange(fset, file.Comments...)
+	require.Len(t, ranges, 3)
+
+	assert.Equal(t, []string{"gocyclo"}, ranges[0].linters)
+	assert.Equal(t, "2000-12-31", ranges[0].until)
+	assert.Equal(t, "refactor tracked in backlog", ranges[0].reason)
+	assert.True(t, ranges[0].expired)
+	assert.False(t, ranges[0].matches(&Issue{Line: 4, Linter: "gocyclo"}))
+
+	assert.Equal(t, []string{"vet"}, ranges[1].linters)
+	assert.False(t, ranges[1].expired)
+	assert.True(t, ranges[1].matches(&Issue{Line: 5, Linter: "vet"}))
+
+	assert.Nil(t, ranges[2].linters)
+	assert.Equal(t, "tomorrow", ranges[2].invalidUntil)
+}
+
+func TestParseUntilExpiresAfterDate(t *testing.T) {
+	now := time.Date(2026, 12, 31, 23, 0, 0, 0, time.Local)
+	rng := &ignoredRange{}
+	assert.Equal(t, "nolint:gocyclo ", parseUntil("nolint:gocyclo until=2026-12-31", rng, now))
+	assert.False(t, rng.expired)
+
+	rng = &ignoredRange{}
+	parseUntil("nolint:gocyclo until=2026-12-31", rng, now.Add(time.Hour))
+	assert.True(t, rng.expired)
+}
+
+func TestLintDirectivesExpiry(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+
+	directives := newDirectiveParser()
+	directives.files["foo.go"] = ignoredRanges{
+		{line: 1, until: "2000-01-01", expired: true},
+		{line: 2, invalidUntil: "soon"},
+		{line: 3, until: "2999-01-01"},
+	}
+	messages := []string{}
+	for _, issue := range lintDirectives(directives) {
+		messages = append(messages, fmt.Sprintf("%d: %s", issue.Line, issue.Message))
+	}
+	assert.Equal(t, []string{
+		"1: nolint directive expired on 2000-01-01",
+		`2: nolint directive has invalid expiry date "soon" (expected YYYY-MM-DD)`,
+	}, messages)
+	assert.Len(t, directives.Unmatched()["foo.go"], 2)
+}
+
+func TestDirectiveParserFileAndPackageLevel(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	mkFile(t, tmpdir, "generated.go", `// Code generated by protoc. DO NOT EDIT.
+
+//nolint:golint
+package foo
+
+func a() {}
+`)
+	mkDir(t, tmpdir, "pkg")
+	mkFile(t, filepath.Join(tmpdir, "pkg"), "doc.go", `// nolint: vet // package-wide
+// Package pkg does things.
+package pkg
+`)
+	mkDir(t, tmpdir, "other")
+	mkFile(t, filepath.Join(tmpdir, "other"), nolintFilename, `# suppressions for the whole package
+nolint: errcheck // legacy code
+// nolint: unused
+`)
+
+	directives := newDirectiveParser()
+	issue := func(path string, line int, linter string) *Issue {
+		return &Issue{Path: newIssuePath(tmpdir, path), Line: line, Linter: linter}
+	}
+	assert.True(t, directives.IsIgnored(issue("generated.go", 6, "golint")))
+	assert.False(t, directives.IsIgnored(issue("generated.go", 6, "vet")))
+	assert.True(t, directives.IsIgnored(issue("pkg/file.go", 1, "vet")))
+	assert.True(t, directives.IsIgnored(issue("pkg/doc.go", 3, "vet")))
+	assert.False(t, directives.IsIgnored(issue("pkg/file.go", 1, "golint")))
+	assert.True(t, directives.IsIgnored(issue("other/file.go", 1, "errcheck")))
+	assert.False(t, directives.IsIgnored(issue("file.go", 1, "errcheck")))
+
+	unmatched := directives.Unmatched()
+	require.Len(t, unmatched[filepath.Join("other", nolintFilename)], 1)
+	assert.Equal(t, 3, unmatched[filepath.Join("other", nolintFilename)][0].line)
+	assert.Empty(t, unmatched["generated.go"])
+	assert.Empty(t, unmatched[filepath.Join("pkg", "doc.go")])
+}
+
+func TestDirectiveParserIsGenerated(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.GeneratedPaths = []string{"*.pb.go", "mocks/*"}
+	config.generatedHeaders = []*regexp.Regexp{regexp.MustCompile(`^// Autogenerated by Thrift`)}
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkFile(t, tmpdir, "gen.go", "// Code generated by mockgen. DO NOT EDIT.\n\npackage foo\n")
+	mkFile(t, tmpdir, "thrift.go", "// Autogenerated by Thrift Compiler\npackage foo\n")
+	mkFile(t, tmpdir, "late.go", "package foo\n\n// Code generated by hand. DO NOT EDIT.\n")
+	mkGoFile(t, tmpdir, "file.go")
+
+	directives := newDirectiveParser()
+	issue := func(path string) *Issue {
+		return &Issue{Path: newIssuePath(tmpdir, path), Line: 1}
+	}
+	assert.True(t, directives.IsGenerated(issue("gen.go")))
+	assert.True(t, directives.IsGenerated(issue("thrift.go")))
+	assert.False(t, directives.IsGenerated(issue("late.go")))
+	assert.False(t, directives.IsGenerated(issue("file.go")))
+	assert.True(t, directives.IsGenerated(issue("api/service.pb.go")))
+	assert.True(t, directives.IsGenerated(issue("mocks/service.go")))
+}
+
+func TestStaticcheckDirectives(t *testing.T) {
+	source := `package foo
+
+//lint:file-ignore U1000 unused helpers are kept for reference
+
+func a() {
+	//lint:ignore SA1019,SA4006 we need the deprecated API
+	b := deprecated()
+	c := 10 //nolint:errcheck
+	//lint:ignore
+	d := 10
+}
+`
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkFile(t, tmpdir, "foo.go", source)
+
+	directives := newDirectiveParser()
+	issue := func(line int, linter, message string) *Issue {
+		return &Issue{Path: newIssuePath(tmpdir, "foo.go"), Line: line, Linter: linter, Message: message}
+	}
+	assert.True(t, directives.IsIgnored(issue(11, "staticcheck", "func x is unused (U1000)")))
+	assert.True(t, directives.IsIgnored(issue(7, "staticcheck", "os.SEEK_SET is deprecated (SA1019)")))
+	assert.False(t, directives.IsIgnored(issue(7, "staticcheck", "should omit nil check (S1009)")))
+	assert.False(t, directives.IsIgnored(issue(7, "vet", "deprecated (SA1019)")))
+	assert.False(t, directives.IsIgnored(issue(8, "staticcheck", "os.SEEK_SET is deprecated (SA1019)")))
+	assert.True(t, directives.IsIgnored(issue(8, "errcheck", "")))
+	assert.False(t, directives.IsIgnored(issue(10, "golint", "")))
+
+	ranges := directives.files["foo.go"]
+	require.Len(t, ranges, 3)
+	syntaxes := map[string]string{}
+	for _, r := range ranges {
+		syntaxes[r.String()] = r.syntax
+	}
+	assert.Equal(t, map[string]string{
+		"staticcheck,gosimple,unused,megacheck(SA1019,SA4006):6-7": staticcheckIgnoreSyntax,
+		"errcheck:8-8": nolintSyntax,
+		"staticcheck,gosimple,unused,megacheck(U1000):1-11": staticcheckFileIgnoreSyntax,
+	}, syntaxes)
+}
+
+func TestDirectiveParserConcurrentLoad(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	mkFile(t, tmpdir, "generated.go", `// Code generated by protoc. DO NOT EDIT.
+
+package foo
+`)
+	mkFile(t, tmpdir, "nolint.go", `package foo
+
+func a() {} // nolint: vet
+`)
+
+	directives := newDirectiveParser()
+	directives.Prewarm([]string{"."}, newCPUBudget(4))
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.True(t, directives.IsIgnored(&Issue{Path: newIssuePath(tmpdir, "nolint.go"), Line: 3, Linter: "vet"}))
+			assert.True(t, directives.IsGenerated(&Issue{Path: newIssuePath(tmpdir, "generated.go"), Line: 3}))
+		}()
+	}
+	wg.Wait()
+	directives.Wait()
+	assert.Len(t, directives.files["nolint.go"], 1)
+	assert.Empty(t, directives.files["generated.go"])
+	assert.Empty(t, directives.loading)
+}
+
+func TestLintDirectivesUnknownLinter(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+
+	directives := newDirectiveParser()
+	directives.files["foo.go"] = ignoredRanges{
+		{line: 1, syntax: nolintSyntax, linters: []string{"errchek", "vet", "nolint"}},
+		{line: 2, syntax: nolintSyntax, linters: []string{"zzzzzzzz"}},
+		{line: 3, syntax: staticcheckIgnoreSyntax, linters: []string{"unknown"}},
+	}
+	messages := []string{}
+	for _, issue := range lintDirectives(directives) {
+		messages = append(messages, fmt.Sprintf("%d: %s", issue.Line, issue.Message))
+	}
+	assert.Equal(t, []string{
+		`1: nolint directive names unknown linter "errchek", did you mean "errcheck"?`,
+		`2: nolint directive names unknown linter "zzzzzzzz"`,
+	}, messages)
+}
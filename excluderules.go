@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// ExcludeRule excludes issues matching all of its non-empty fields. Unlike
+// --exclude, rules match fields of the issue rather than the formatted
+// output, so they do not depend on --format.
+type ExcludeRule struct {
+	// Name of the linter that reported the issue.
+	Linter string
+	// Check ID that must appear in the message, eg. "SA1019" or "G104".
+	Rule string
+	// Glob matching the path of the issue, or the base name of the path.
+	Path string
+	// Regular expression matching the message.
+	Message string
+	// Severity of the issue, "error" or "warning".
+	Severity string
+	// Regular expression matching the source line of the issue.
+	Source string
+	// Why the issues are excluded.
+	Reason string
+	// Date in the form YYYY-MM-DD after which the rule no longer applies.
+	Until string
+
+	rule    *regexp.Regexp
+	message *regexp.Regexp
+	source  *regexp.Regexp
+	expired bool
+	hits    int
+}
+
+// compile validates the rule and compiles its regular expressions.
+func (r *ExcludeRule) compile(now time.Time) (err error) {
+	if r.Linter == "" && r.Rule == "" && r.Path == "" && r.Message == "" && r.Severity == "" && r.Source == "" {
+		return fmt.Errorf("rule must match at least one field")
+	}
+	if r.Rule != "" {
+		r.rule = regexp.MustCompile(`\b` + regexp.QuoteMeta(r.Rule) + `\b`)
+	}
+	if r.Message != "" {
+		if r.message, err = regexp.Compile(r.Message); err != nil {
+			return fmt.Errorf("invalid message pattern: %s", err)
+		}
+	}
+	if r.Source != "" {
+		if r.source, err = regexp.Compile(r.Source); err != nil {
+			return fmt.Errorf("invalid source pattern: %s", err)
+		}
+	}
+	if r.Severity != "" && Severity(r.Severity) != Error && Severity(r.Severity) != Warning {
+		return fmt.Errorf("invalid severity %q", r.Severity)
+	}
+	if r.Path != "" {
+		if _, err := filepath.Match(r.Path, ""); err != nil {
+			return fmt.Errorf("invalid path glob %q: %s", r.Path, err)
+		}
+	}
+	if r.Until != "" {
+		until, err := time.ParseInLocation(nolintUntilDate, r.Until, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid expiry date %q (expected YYYY-MM-DD)", r.Until)
+		}
+		r.expired = !now.Before(until.AddDate(0, 0, 1))
+	}
+	return nil
+}
+
+func (r *ExcludeRule) matches(issue *Issue, sourceLine func(*Issue) string) bool {
+	if r.expired {
+		return false
+	}
+	if r.Linter != "" && !containsString(issue.LinterNames(), r.Linter) {
+		return false
+	}
+	if r.Severity != "" && Severity(r.Severity) != issue.Severity {
+		return false
+	}
+	if r.Path != "" && !matchesPathGlob(r.Path, issue.Path.Relative()) {
+		return false
+	}
+	if r.rule != nil && !r.rule.MatchString(issue.Message) {
+		return false
+	}
+	if r.message != nil && !r.message.MatchString(issue.Message) {
+		return false
+	}
+	if r.source != nil && !r.source.MatchString(sourceLine(issue)) {
+		return false
+	}
+	return true
+}
+
+func (r *ExcludeRule) String() string {
+	s := ""
+	add := func(key, value string) {
+		if value != "" {
+			if s != "" {
+				s += " "
+			}
+			s += fmt.Sprintf("%s=%q", key, value)
+		}
+	}
+	add("linter", r.Linter)
+	add("rule", r.Rule)
+	add("path", r.Path)
+	add("message", r.Message)
+	add("severity", r.Severity)
+	add("source", r.Source)
+	return s
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceLines reads and caches the lines of source files, keyed by absolute
+// path so that cached lines remain valid if the working directory changes.
+type sourceLines map[string][]string
+
+func (s sourceLines) file(path string) []string {
+	lines, ok := s[path]
+	if !ok {
+		lines = readLines(path)
+		s[path] = lines
+	}
+	return lines
+}
+
+func (s sourceLines) line(issue *Issue) string {
+	lines := s.file(issue.Path.Abs())
+	if issue.Line < 1 || issue.Line > len(lines) {
+		return ""
+	}
+	return lines[issue.Line-1]
+}
+
+func readLines(path string) []string {
+	r, err := os.Open(path)
+	if err != nil {
+		debug("failed to read %s: %s", path, err)
+		return nil
+	}
+	defer r.Close() // nolint: errcheck
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+// filterIssuesViaExcludeRules removes issues matching any of the exclude
+// rules, counting the issues excluded by each rule.
+func filterIssuesViaExcludeRules(rules []*ExcludeRule, issues chan *Issue) chan *Issue {
+	if len(rules) == 0 {
+		return issues
+	}
+	out := make(chan *Issue, 1000000)
+	go func() {
+		lines := sourceLines{}
+	next:
+		for issue := range issues {
+			for _, rule := range rules {
+				if rule.matches(issue, lines.line) {
+					rule.hits++
+					stats.suppressed(suppressedByExcludeRules, 1)
+					continue next
+				}
+			}
+			out <- issue
+		}
+		reportExcludeRules(rules)
+		close(out)
+	}()
+	return out
+}
+
+// reportExcludeRules warns about rules that have expired or did not exclude
+// any issues, and with --exclude-rules-report lists the number of issues excluded by each.
+func reportExcludeRules(rules []*ExcludeRule) {
+	for i, rule := range rules {
+		status := fmt.Sprintf("%d issues excluded", rule.hits)
+		if rule.expired {
+			status = fmt.Sprintf("expired on %s", rule.Until)
+		}
+		debug("exclude rule %d (%s): %s", i+1, rule, status)
+		if config.ExcludeRulesReport {
+			reason := rule.Reason
+			if reason == "" {
+				reason = "no reason"
+			}
+			fmt.Fprintf(os.Stderr, "exclude rule %d (%s) %s: %s\n", i+1, reason, rule, status)
+		}
+		switch {
+		case rule.expired:
+			warning("exclude rule %d (%s) expired on %s", i+1, rule, rule.Until)
+		case rule.hits == 0 && !config.FailFast:
+			warning("exclude rule %d (%s) did not match any issues", i+1, rule)
+		}
+	}
+}
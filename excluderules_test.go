@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcludeRuleMatches(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkFile(t, tmpdir, "a.go", "package foo\n\nvar _ = ioutil.ReadAll // deprecated\n")
+
+	issue := &Issue{
+		Linter:   "staticcheck",
+		Severity: Warning,
+		Path:     newIssuePath(tmpdir, "a.go"),
+		Line:     3,
+		Message:  "ioutil.ReadAll is deprecated (SA1019)",
+	}
+	var testcases = []struct {
+		doc      string
+		rule     ExcludeRule
+		expected bool
+	}{
+		{doc: "linter", rule: ExcludeRule{Linter: "staticcheck"}, expected: true},
+		{doc: "other linter", rule: ExcludeRule{Linter: "vet"}},
+		{doc: "rule", rule: ExcludeRule{Rule: "SA1019"}, expected: true},
+		{doc: "rule prefix", rule: ExcludeRule{Rule: "SA10"}},
+		{doc: "path glob", rule: ExcludeRule{Path: "*.go", Linter: "staticcheck"}, expected: true},
+		{doc: "other path", rule: ExcludeRule{Path: "b.go"}},
+		{doc: "message", rule: ExcludeRule{Message: "is deprecated"}, expected: true},
+		{doc: "severity", rule: ExcludeRule{Severity: "error"}},
+		{doc: "source", rule: ExcludeRule{Source: `//\s*deprecated`}, expected: true},
+		{doc: "other source", rule: ExcludeRule{Source: `ReadFile`}},
+		{doc: "expired", rule: ExcludeRule{Linter: "staticcheck", Until: "2000-01-01"}},
+		{doc: "not expired", rule: ExcludeRule{Linter: "staticcheck", Until: "2999-01-01"}, expected: true},
+	}
+	for _, testcase := range testcases {
+		rule := testcase.rule
+		require.NoError(t, rule.compile(time.Now()), testcase.doc)
+		assert.Equal(t, testcase.expected, rule.matches(issue, sourceLines{}.line), testcase.doc)
+	}
+}
+
+func TestExcludeRuleCompileErrors(t *testing.T) {
+	for _, rule := range []ExcludeRule{
+		{},
+		{Message: "("},
+		{Source: "["},
+		{Path: "["},
+		{Severity: "fatal"},
+		{Linter: "vet", Until: "tomorrow"},
+	} {
+		assert.Error(t, rule.compile(time.Now()), rule.String())
+	}
+}
+
+func TestFilterIssuesViaExcludeRules(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+
+	rules := []*ExcludeRule{{Linter: "vet"}, {Linter: "golint"}}
+	for _, rule := range rules {
+		require.NoError(t, rule.compile(time.Now()))
+	}
+	issues := make(chan *Issue, 3)
+	issues <- &Issue{Linter: "vet"}
+	issues <- &Issue{Linter: "vet"}
+	issues <- &Issue{Linter: "errcheck"}
+	close(issues)
+
+	out := []*Issue{}
+	for issue := range filterIssuesViaExcludeRules(rules, issues) {
+		out = append(out, issue)
+	}
+	require.Len(t, out, 1)
+	assert.Equal(t, "errcheck", out[0].Linter)
+	assert.Equal(t, 2, rules[0].hits)
+	assert.Equal(t, 0, rules[1].hits)
+}
@@ -1,12 +1,13 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -46,6 +47,27 @@ type linterState struct {
 	exclude  *regexp.Regexp
 	include  *regexp.Regexp
 	deadline <-chan time.Time
+	cancel   <-chan struct{}
+	budget   *cpuBudget
+	// Directives that observe every issue before it is filtered, if set.
+	directives *directiveParser
+	// Set if the output of any partition exceeded --max-linter-output.
+	lock      sync.Mutex
+	truncated bool
+}
+
+func (l *linterState) setTruncated() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.truncated = true
+}
+
+// Truncated returns true if issues reported by the linter may have been
+// discarded because its output was too long.
+func (l *linterState) Truncated() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.truncated
 }
 
 func (l *linterState) Partitions(paths []string) ([][]string, error) {
@@ -57,25 +79,113 @@ func (l *linterState) Partitions(paths []string) ([][]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	return parts, nil
+	workDir, err := l.workDir()
+	if err != nil || workDir == "" {
+		return parts, err
+	}
+	// Partition strategies may glob files in the paths, so they are given
+	// paths relative to the current directory and the resulting arguments are
+	// rewritten afterwards.
+	return partitionsRelativeTo(workDir, parts, len(cmdArgs), paths)
 }
 
 func (l *linterState) command() string {
 	return l.vars.Replace(l.Command)
 }
 
+// workDir returns the absolute directory the linter is run from, or an empty
+// string if it runs from the current directory.
+func (l *linterState) workDir() (string, error) {
+	if l.WorkDir == "" {
+		return "", nil
+	}
+	return filepath.Abs(l.vars.Replace(l.WorkDir))
+}
+
+// environ returns the environment the linter is run with.
+func (l *linterState) environ(procs int) []string {
+	env := os.Environ()
+	if procs > 0 {
+		env = append(env, fmt.Sprintf("GOMAXPROCS=%d", procs))
+	}
+	keys := make([]string, 0, len(l.Env))
+	for key := range l.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+l.vars.Replace(l.Env[key]))
+	}
+	return env
+}
+
+// pathsRelativeTo rewrites paths so that they are relative to dir.
+func pathsRelativeTo(dir string, paths []string) ([]string, error) {
+	out := make([]string, 0, len(paths))
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, relativePackagePath(rel))
+	}
+	return out, nil
+}
+
+// partitionsRelativeTo rewrites the arguments following the command in each
+// partition so that paths, and files within them, are relative to dir.
+// Arguments that are not paths, such as import paths, are left unchanged.
+func partitionsRelativeTo(dir string, parts [][]string, commandArgs int, paths []string) ([][]string, error) {
+	relative, err := pathsRelativeTo(dir, paths)
+	if err != nil {
+		return nil, err
+	}
+	rewrite := map[string]string{}
+	for i, path := range paths {
+		rewrite[filepath.Clean(path)] = relative[i]
+	}
+	out := make([][]string, 0, len(parts))
+	for _, part := range parts {
+		args := append([]string{}, part[:commandArgs]...)
+		for _, arg := range part[commandArgs:] {
+			if path, ok := rewrite[filepath.Clean(arg)]; ok {
+				arg = path
+			} else if path, ok := rewrite[filepath.Dir(arg)]; ok {
+				arg = filepath.Join(path, filepath.Base(arg))
+			}
+			args = append(args, arg)
+		}
+		out = append(out, args)
+	}
+	return out, nil
+}
+
 func runLinters(linters map[string]*Linter, paths []string, concurrency int, exclude, include *regexp.Regexp) (chan *Issue, chan error) {
 	errch := make(chan error, len(linters))
 	concurrencych := make(chan bool, concurrency)
 	incomingIssues := make(chan *Issue, 1000000)
 
+	// Directives are parsed when the first issue in each file arrives, unless
+	// they are needed from every file, in which case they are parsed while
+	// linters are running.
+	budget := newCPUBudget(concurrency)
 	directiveParser := newDirectiveParser()
-	if config.WarnUnmatchedDirective {
-		directiveParser.LoadFiles(paths)
+	if loadAllDirectives() {
+		directiveParser.Prewarm(paths, budget)
+	}
+
+	// Closing cancel stops any queued partitions and kills running linters.
+	cancel := make(chan struct{})
+	cancelOnce := sync.Once{}
+	cancelLinters := func() {
+		cancelOnce.Do(func() { close(cancel) })
 	}
 
-	processedIssues := maybeSortIssues(filterIssuesViaDirectives(
-		directiveParser, maybeAggregateIssues(incomingIssues)))
+	processedIssues := processIssues(directiveParser, incomingIssues, cancelLinters)
 
 	vars := Vars{
 		"duplthreshold":    fmt.Sprintf("%d", config.DuplThreshold),
@@ -93,50 +203,120 @@ func runLinters(linters map[string]*Linter, paths []string, concurrency int, exc
 		vars["not_tests"] = ""
 	}
 
-	wg := &sync.WaitGroup{}
-	id := 1
-	for _, linter := range linters {
-		deadline := time.After(config.Deadline.Duration())
-		state := &linterState{
-			Linter:   linter,
-			issues:   incomingIssues,
-			vars:     vars,
-			exclude:  exclude,
-			include:  include,
-			deadline: deadline,
+	reportError := func(linter string, err error) {
+		events.linterFailed(linter, err)
+		stats.linterFailed(linter, err)
+		errch <- err
+		if config.FailFast {
+			cancelLinters()
 		}
+	}
 
-		partitions, err := state.Partitions(paths)
-		if err != nil {
-			errch <- err
-			continue
+	go func() {
+		type job struct {
+			state *linterState
+			args  []string
 		}
-		for _, args := range partitions {
+		jobs := []job{}
+		// Linters which failed or timed out on at least one partition.
+		failed := map[string]bool{}
+		failedLock := sync.Mutex{}
+		for _, linter := range linters {
+			deadline := time.After(config.Deadline.Duration())
+			state := &linterState{
+				Linter:   linter,
+				issues:   incomingIssues,
+				vars:     vars,
+				exclude:  exclude,
+				include:  include,
+				deadline: deadline,
+				cancel:   cancel,
+				budget:   budget,
+			}
+			if reportsDirectiveMatches() {
+				state.directives = directiveParser
+			}
+
+			partitions, err := state.Partitions(paths)
+			if err != nil {
+				failed[linter.Name] = true
+				reportError(linter.Name, err)
+				continue
+			}
+			for _, args := range partitions {
+				jobs = append(jobs, job{state: state, args: args})
+			}
+			budget.queue(len(partitions))
+		}
+
+		wg := &sync.WaitGroup{}
+	dispatch:
+		for i, job := range jobs {
+			select {
+			case concurrencych <- true:
+			case <-cancel:
+				break dispatch
+			}
 			wg.Add(1)
-			concurrencych <- true
-			// Call the goroutine with a copy of the args array so that the
-			// contents of the array are not modified by the next iteration of
-			// the above for loop
-			go func(id int, args []string) {
+			go func(id int, state *linterState, args []string) {
+				start := time.Now()
+				events.linterStarted(state.Name, id)
 				err := executeLinter(id, state, args)
+				events.linterFinished(state.Name, id, time.Since(start))
 				if err != nil {
-					errch <- err
+					failedLock.Lock()
+					failed[state.Name] = true
+					failedLock.Unlock()
+					reportError(state.Name, err)
 				}
 				<-concurrencych
 				wg.Done()
-			}(id, args)
-			id++
+			}(i+1, job.state, job.args)
 		}
-	}
 
-	go func() {
 		wg.Wait()
+		// Issues from linters with truncated output were discarded, so their
+		// directives may have matched and must not be considered unmatched.
+		for _, job := range jobs {
+			if job.state.Truncated() {
+				failed[job.state.Name] = true
+			}
+		}
+		ran := newStringSet()
+		select {
+		case <-cancel:
+		default:
+			for name := range linters {
+				if !failed[name] {
+					ran.add(name)
+				}
+			}
+		}
+		directiveParser.lintersRun = ran
 		close(incomingIssues)
+		// Directive reports are written once all issues have been filtered,
+		// which may be after --fail-fast has closed the issue channel.
+		directiveParser.WaitFiltered()
 		close(errch)
 	}()
 	return processedIssues, errch
 }
 
+// deadlineError is returned when a linter is killed for exceeding --deadline,
+// along with any of its output that was not parsed for issues.
+type deadlineError struct {
+	linter string
+	output string
+}
+
+func (e *deadlineError) Error() string {
+	msg := fmt.Sprintf("deadline exceeded by linter %s (try increasing --deadline)", e.linter)
+	if e.output != "" {
+		msg += "\n" + e.output
+	}
+	return msg
+}
+
 func executeLinter(id int, state *linterState, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("missing linter command")
@@ -145,17 +325,35 @@ func executeLinter(id int, state *linterState, args []string) error {
 	start := time.Now()
 	dbg := namespacedDebug(fmt.Sprintf("[%s.%d]: ", state.Name, id))
 	dbg("executing %s", strings.Join(args, " "))
-	buf := bytes.NewBuffer(nil)
+	workDir, err := state.workDir()
+	if err != nil {
+		return err
+	}
+	parser := newOutputParser(dbg, state, workDir)
+	// Patterns that only match within a single line are applied to the output
+	// as it arrives, others need the complete output.
+	output := newLinterOutput(state.Linter, parser.parse, int64(config.MaxLinterOutput))
 	command := args[0]
 	cmd := exec.Command(command, args[1:]...) // nolint: gosec
-	cmd.Stdout = buf
-	cmd.Stderr = buf
-	err := cmd.Start()
+	cmd.Stdout = output.Stdout()
+	cmd.Stderr = output.Stderr()
+	cmd.Dir = workDir
+	procs := 0
+	if state.budget != nil {
+		procs = state.budget.acquire(state.MaxProcs)
+		defer state.budget.release(procs)
+		dbg("GOMAXPROCS=%d (%d of %d CPUs unallocated)", procs, state.budget.free(), state.budget.total)
+	}
+	cmd.Env = state.environ(procs)
+	if workDir != "" {
+		dbg("working directory %s", workDir)
+	}
+	err = cmd.Start()
 	if err != nil {
 		return fmt.Errorf("failed to execute linter %s: %s", command, err)
 	}
 
-	done := make(chan error)
+	done := make(chan error, 1)
 	go func() {
 		done <- cmd.Wait()
 	}()
@@ -165,22 +363,46 @@ func executeLinter(id int, state *linterState, args []string) error {
 	case err = <-done:
 
 	case <-state.deadline:
-		err = fmt.Errorf("deadline exceeded by linter %s (try increasing --deadline)",
-			state.Name)
+		output.Stop()
+		err = &deadlineError{linter: state.Name, output: output.Captured()}
 		kerr := cmd.Process.Kill()
 		if kerr != nil {
 			warning("failed to kill %s: %s", state.Name, kerr)
 		}
 		return err
+
+	case <-state.cancel:
+		dbg("cancelled")
+		output.Stop()
+		kerr := cmd.Process.Kill()
+		if kerr != nil {
+			warning("failed to kill %s: %s", state.Name, kerr)
+		}
+		return nil
 	}
 
+	output.Close()
+	if output.Truncated() {
+		state.setTruncated()
+		warning("output of %s exceeded %s, the remainder was discarded (see --max-linter-output)",
+			state.Name, config.MaxLinterOutput)
+	}
 	if err != nil {
-		dbg("warning: %s returned %s: %s", command, err, buf.String())
+		dbg("warning: %s returned %s: %s", command, err, output.All())
 	}
+	dbg("%s hits %d: %s", state.Name, parser.hits, state.Pattern)
 
-	processOutput(dbg, state, buf.Bytes())
 	elapsed := time.Since(start)
 	dbg("%s linter took %s", state.Name, elapsed)
+	if errors := output.Errors(); len(errors) > 0 {
+		return &linterError{linter: state.Name, messages: errors, output: output.Captured()}
+	}
+	// Linters exit with an error when they report issues, so the exit status
+	// alone is only a failure if nothing was parsed and unparsed output
+	// explains why.
+	if captured := output.Captured(); err != nil && parser.hits == 0 && captured != "" {
+		return &linterError{linter: state.Name, messages: []string{err.Error()}, output: captured}
+	}
 	return nil
 }
 
@@ -199,19 +421,62 @@ func parseCommand(command string) ([]string, error) {
 	return append([]string{exe}, args[1:]...), nil
 }
 
-// nolint: gocyclo
-func processOutput(dbg debugFunction, state *linterState, out []byte) {
-	re := state.regex
-	all := re.FindAllSubmatchIndex(out, -1)
-	dbg("%s hits %d: %s", state.Name, len(all), state.Pattern)
+// outputParser extracts issues from the output of a single linter invocation
+// and sends them to the linter's issue channel.
+type outputParser struct {
+	dbg     debugFunction
+	state   *linterState
+	cwd     string
+	workDir string
+	vars    Vars
+	hits    int
+}
 
+// newOutputParser creates a parser for the output of a linter run from
+// workDir, or from the current directory if workDir is empty.
+func newOutputParser(dbg debugFunction, state *linterState, workDir string) *outputParser {
 	cwd, err := os.Getwd()
 	if err != nil {
 		warning("failed to get working directory %s", err)
 	}
+	return &outputParser{
+		dbg:     dbg,
+		state:   state,
+		cwd:     cwd,
+		workDir: workDir,
+		// Create a local copy of vars so they can be modified by the linter output
+		vars: state.vars.Copy(),
+	}
+}
+
+// issuePath converts a path reported by the linter to an IssuePath. Paths
+// relative to the linter's working directory are made relative to the current
+// directory.
+func (p *outputParser) issuePath(path string) IssuePath {
+	if p.workDir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(p.workDir, path)
+	}
+	issuePath, err := newIssuePathFromAbsPath(p.cwd, path)
+	if err != nil {
+		warning("failed to make %s a relative path: %s", path, err)
+	}
+	return issuePath
+}
 
-	// Create a local copy of vars so they can be modified by the linter output
-	vars := state.vars.Copy()
+func parsePatternInt(name, part string) int {
+	n, err := strconv.ParseInt(part, 10, 32)
+	kingpin.FatalIfError(err, "%s matched invalid integer", name)
+	return int(n)
+}
+
+// parse extracts all issues from a chunk of linter output.
+// nolint: gocyclo
+func (p *outputParser) parse(out []byte) {
+	state := p.state
+	vars := p.vars
+	re := state.regex
+	all := re.FindAllSubmatchIndex(out, -1)
+	p.hits += len(all)
 
 	for _, indices := range all {
 		group := [][]byte{}
@@ -225,6 +490,8 @@ func processOutput(dbg debugFunction, state *linterState, out []byte) {
 
 		issue, err := NewIssue(state.Linter.Name, config.formatTemplate)
 		kingpin.FatalIfError(err, "Invalid output format")
+		related := Location{}
+		relatedPath := ""
 
 		for i, name := range re.SubexpNames() {
 			if group[i] == nil {
@@ -236,19 +503,31 @@ func processOutput(dbg debugFunction, state *linterState, out []byte) {
 			}
 			switch name {
 			case "path":
-				issue.Path, err = newIssuePathFromAbsPath(cwd, part)
-				if err != nil {
-					warning("failed to make %s a relative path: %s", part, err)
-				}
+				issue.Path = p.issuePath(part)
+
 			case "line":
-				n, err := strconv.ParseInt(part, 10, 32)
-				kingpin.FatalIfError(err, "line matched invalid integer")
-				issue.Line = int(n)
+				issue.Line = parsePatternInt(name, part)
 
 			case "col":
-				n, err := strconv.ParseInt(part, 10, 32)
-				kingpin.FatalIfError(err, "col matched invalid integer")
-				issue.Col = int(n)
+				issue.Col = parsePatternInt(name, part)
+
+			case "end_line":
+				issue.EndLine = parsePatternInt(name, part)
+
+			case "end_col":
+				issue.EndCol = parsePatternInt(name, part)
+
+			case "related_path":
+				relatedPath = part
+
+			case "related_line":
+				related.Line = parsePatternInt(name, part)
+
+			case "related_col":
+				related.Col = parsePatternInt(name, part)
+
+			case "related_end_line":
+				related.EndLine = parsePatternInt(name, part)
 
 			case "message":
 				issue.Message = part
@@ -256,6 +535,14 @@ func processOutput(dbg debugFunction, state *linterState, out []byte) {
 			case "":
 			}
 		}
+		if related.Line != 0 {
+			// A related line without a path is in the same file as the issue.
+			related.Path = issue.Path
+			if relatedPath != "" {
+				related.Path = p.issuePath(relatedPath)
+			}
+			issue.Related = append(issue.Related, related)
+		}
 		// TODO: set messageOveride and severity on the Linter instead of reading
 		// them directly from the static config
 		if m, ok := config.MessageOverride[state.Name]; ok {
@@ -264,16 +551,53 @@ func processOutput(dbg debugFunction, state *linterState, out []byte) {
 		if sev, ok := config.Severity[state.Name]; ok {
 			issue.Severity = Severity(sev)
 		}
+		if state.directives != nil {
+			state.directives.Observe(issue)
+		}
 		if state.exclude != nil && state.exclude.MatchString(issue.String()) {
+			stats.suppressed(suppressedByExclude, 1)
 			continue
 		}
 		if state.include != nil && !state.include.MatchString(issue.String()) {
+			stats.suppressed(suppressedByExclude, 1)
 			continue
 		}
 		state.issues <- issue
 	}
 }
 
+// processIssues filters, aggregates, sorts and limits issues from linters.
+func processIssues(directives *directiveParser, issues chan *Issue, cancel func()) chan *Issue {
+	filtered := filterIssuesViaExcludeRules(config.ExcludeRules, filterIssuesViaDirectives(directives, issues))
+	if config.FailFast {
+		// Sorting and aggregation would hold back the first issue until every
+		// linter has completed, so they are bypassed.
+		return failFastIssues(filtered, cancel)
+	}
+	// Issues are filtered before they are aggregated, so that a directive or
+	// exclude rule for one linter does not remove an aggregated issue that
+	// other linters also reported. With --errors, other issues are removed
+	// before limiting so that they do not count towards the limits.
+	return maybeLimitIssues(maybeErrorsOnly(maybeSortIssues(maybeAggregateIssues(filtered))))
+}
+
+// failFastIssues passes issues through until the first one that would be
+// reported, then calls cancel and closes the returned channel.
+func failFastIssues(issues chan *Issue, cancel func()) chan *Issue {
+	out := make(chan *Issue, 1000000)
+	go func() {
+		for issue := range issues {
+			out <- issue
+			if !config.Errors || issue.Severity == Error {
+				cancel()
+				break
+			}
+		}
+		close(out)
+	}()
+	return out
+}
+
 func maybeSortIssues(issues chan *Issue) chan *Issue {
 	if reflect.DeepEqual([]string{"none"}, config.Sort) {
 		return issues
@@ -281,9 +605,37 @@ func maybeSortIssues(issues chan *Issue) chan *Issue {
 	return SortIssueChan(issues, config.Sort)
 }
 
+func maybeErrorsOnly(issues chan *Issue) chan *Issue {
+	if !config.Errors {
+		return issues
+	}
+	out := make(chan *Issue, 1000000)
+	go func() {
+		for issue := range issues {
+			if issue.Severity == Error {
+				out <- issue
+			}
+		}
+		close(out)
+	}()
+	return out
+}
+
+func maybeLimitIssues(issues chan *Issue) chan *Issue {
+	if config.MaxIssuesPerLinter <= 0 && config.MaxSameIssues <= 0 {
+		return issues
+	}
+	// The last issue from each linter is held back until all issues have been
+	// read, so limited issues are sorted again.
+	return maybeSortIssues(LimitIssueChan(issues, config.MaxIssuesPerLinter, config.MaxSameIssues))
+}
+
 func maybeAggregateIssues(issues chan *Issue) chan *Issue {
 	if !config.Aggregate {
 		return issues
 	}
+	if config.AggregateBy == aggregateByPosition {
+		return AggregateIssueChanByPosition(issues, config.AggregateWindow)
+	}
 	return AggregateIssueChan(issues)
 }
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLinterStateCommand(t *testing.T) {
@@ -65,3 +71,330 @@ func TestLinterStateCommand(t *testing.T) {
 		assert.Equal(t, testcase.expected, ls.command())
 	}
 }
+
+func TestFailFastIssues(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.Errors = true
+
+	issues := make(chan *Issue, 3)
+	issues <- &Issue{Linter: "golint", Severity: Warning}
+	issues <- &Issue{Linter: "vet", Severity: Error}
+	issues <- &Issue{Linter: "gotype", Severity: Error}
+	close(issues)
+
+	cancelled := 0
+	actual := []string{}
+	for issue := range failFastIssues(issues, func() { cancelled++ }) {
+		actual = append(actual, issue.Linter)
+	}
+	assert.Equal(t, []string{"golint", "vet"}, actual)
+	assert.Equal(t, 1, cancelled)
+}
+
+func TestProcessIssuesFiltersBeforeAggregating(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.Aggregate = true
+	config.AggregateBy = aggregateByPosition
+	config.Sort = []string{"none"}
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkFile(t, tmpdir, "a.go", "package foo\n\nimport . \"fmt\" // nolint: golint\n")
+
+	path := newIssuePath(tmpdir, "a.go")
+	issues := make(chan *Issue, 2)
+	issues <- &Issue{Linter: "golint", Severity: Warning, Path: path, Line: 3, Message: "should not use dot imports"}
+	issues <- &Issue{Linter: "vet", Severity: Warning, Path: path, Line: 3, Message: "dot import"}
+	close(issues)
+
+	actual := []*Issue{}
+	for issue := range processIssues(newDirectiveParser(), issues, func() {}) {
+		actual = append(actual, issue)
+	}
+	require.Len(t, actual, 1)
+	assert.Equal(t, "vet", actual[0].Linter)
+	assert.Empty(t, actual[0].Secondary)
+}
+
+func TestProcessIssuesFiltersErrorsBeforeLimiting(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.Errors = true
+	config.MaxIssuesPerLinter = 1
+	config.Sort = []string{"none"}
+
+	path := newIssuePath("", "a.go")
+	issues := make(chan *Issue, 3)
+	issues <- &Issue{Linter: "vet", Severity: Warning, Path: path, Line: 1, Message: "first"}
+	issues <- &Issue{Linter: "vet", Severity: Warning, Path: path, Line: 2, Message: "second"}
+	issues <- &Issue{Linter: "vet", Severity: Error, Path: path, Line: 3, Message: "third"}
+	close(issues)
+
+	actual := []*Issue{}
+	for issue := range processIssues(newDirectiveParser(), issues, func() {}) {
+		actual = append(actual, issue)
+	}
+	require.Len(t, actual, 1)
+	assert.Equal(t, "third", actual[0].Message)
+}
+
+func TestExecuteLinterCancel(t *testing.T) {
+	cancel := make(chan struct{})
+	close(cancel)
+	state := &linterState{
+		Linter: &Linter{Name: "sleep"},
+		cancel: cancel,
+	}
+	start := time.Now()
+	err := executeLinter(1, state, []string{"sleep", "10"})
+	require.NoError(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestExecuteLinterReportsStderr(t *testing.T) {
+	linter, err := NewLinter("custom", LinterConfig{Pattern: "PATH:LINE:MESSAGE", Streams: "stdout"})
+	require.NoError(t, err)
+
+	state := &linterState{Linter: linter, vars: Vars{}}
+	err = executeLinter(1, state, []string{"sh", "-c", "echo 'no packages to lint' >&2; exit 2"})
+	require.Error(t, err)
+	assert.Equal(t, "linter custom failed: exit status 2\nno packages to lint", err.Error())
+
+	state = &linterState{Linter: linter, vars: Vars{}, deadline: time.After(500 * time.Millisecond)}
+	err = executeLinter(1, state, []string{"sh", "-c", "echo 'waiting for lock' >&2; sleep 10"})
+	require.IsType(t, &deadlineError{}, err)
+	assert.Equal(t, "waiting for lock", err.(*deadlineError).output)
+}
+
+func TestExecuteLinterRecordsTruncation(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+	config.MaxLinterOutput = 10
+
+	linter, err := NewLinter("custom", LinterConfig{Pattern: "PATH:LINE:MESSAGE"})
+	require.NoError(t, err)
+	issues := make(chan *Issue, 10)
+	state := &linterState{Linter: linter, issues: issues, vars: Vars{}}
+	require.NoError(t, executeLinter(1, state, []string{"sh", "-c", "echo a.go:1: first; echo a.go:2: second"}))
+	assert.True(t, state.Truncated())
+}
+
+func TestExecuteLinterIgnoresOutputAfterDeadline(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+
+	linter, err := NewLinter("custom", LinterConfig{Pattern: "PATH:LINE:MESSAGE"})
+	require.NoError(t, err)
+
+	// The backgrounded child outlives the killed shell and keeps its output
+	// open after the issue channel has been closed.
+	issues := make(chan *Issue, 10)
+	state := &linterState{Linter: linter, issues: issues, vars: Vars{}, deadline: time.After(100 * time.Millisecond)}
+	err = executeLinter(1, state, []string{"sh", "-c", "(sleep 0.3; echo a.go:1: late) & sleep 10"})
+	require.IsType(t, &deadlineError{}, err)
+	close(issues)
+	time.Sleep(500 * time.Millisecond)
+	assert.Len(t, issues, 0)
+}
+
+func TestExecuteLinterEnvAndWorkDir(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkDir(t, tmpdir, "sub")
+
+	linter, err := NewLinter("custom", LinterConfig{
+		Pattern: "PATH:LINE:MESSAGE",
+		Env:     map[string]string{"MESSAGE": "{greeting} world"},
+		WorkDir: "sub",
+	})
+	require.NoError(t, err)
+	issues := make(chan *Issue, 10)
+	state := &linterState{
+		Linter: linter,
+		issues: issues,
+		vars:   Vars{"greeting": "hello"},
+	}
+	err = executeLinter(1, state, []string{"sh", "-c", `echo "file.go:3: $MESSAGE from $(basename $PWD)"`})
+	require.NoError(t, err)
+	close(issues)
+
+	issue := <-issues
+	require.NotNil(t, issue)
+	assert.Equal(t, "sub/file.go", issue.Path.Relative())
+	assert.Equal(t, 3, issue.Line)
+	assert.Equal(t, "hello world from sub", issue.Message)
+}
+
+func TestPathsRelativeTo(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	paths, err := pathsRelativeTo(filepath.Join(tmpdir, "sub"), []string{".", "./sub/pkg", filepath.Join(tmpdir, "other")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"..", "./pkg", "../other"}, paths)
+}
+
+func TestLinterStatePartitionsWithWorkDir(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkDir(t, tmpdir, "sub")
+	mkDir(t, tmpdir, "sub", "pkg")
+	mkGoFile(t, filepath.Join(tmpdir, "sub", "pkg"), "other.go")
+	mkDir(t, tmpdir, "other")
+
+	var testcases = []struct {
+		strategy partitionStrategy
+		expected [][]string
+	}{
+		{
+			strategy: partitionPathsAsFiles,
+			expected: [][]string{{"pkg/file.go", "pkg/other.go", "../other/file.go"}},
+		},
+		{
+			strategy: partitionPathsAsFilesGroupedByPackage,
+			expected: [][]string{{"pkg/file.go", "pkg/other.go"}, {"../other/file.go"}},
+		},
+		{
+			strategy: partitionPathsAsDirectories,
+			expected: [][]string{{"./pkg", "../other"}},
+		},
+	}
+	for _, testcase := range testcases {
+		state := &linterState{
+			Linter: &Linter{LinterConfig: LinterConfig{Command: "true", WorkDir: "sub", PartitionStrategy: testcase.strategy}},
+			vars:   Vars{},
+		}
+		parts, err := state.Partitions([]string{"./sub/pkg", "./other"})
+		require.NoError(t, err)
+		require.Len(t, parts, len(testcase.expected))
+		for i, part := range parts {
+			assert.Equal(t, testcase.expected[i], part[1:])
+		}
+	}
+}
+
+func TestOutputParserObservesExcludedIssues(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkFile(t, tmpdir, "a.go", "package foo\n\nvar a = 1 // nolint: vet\n")
+
+	linter, err := NewLinter("vet", LinterConfig{Pattern: "PATH:LINE:MESSAGE"})
+	require.NoError(t, err)
+	directives := newDirectiveParser()
+	issues := make(chan *Issue, 10)
+	state := &linterState{
+		Linter:     linter,
+		issues:     issues,
+		vars:       Vars{},
+		exclude:    regexp.MustCompile("excluded"),
+		directives: directives,
+	}
+	newOutputParser(debug, state, "").parse([]byte("a.go:3: excluded by --exclude"))
+	close(issues)
+
+	assert.Empty(t, issues)
+	require.Len(t, directives.files["a.go"], 1)
+	assert.True(t, directives.files["a.go"][0].matched)
+}
+
+func TestOutputParserRelatedLocations(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkGoFile(t, tmpdir, "a.go")
+	mkGoFile(t, tmpdir, "b.go")
+
+	var testcases = []struct {
+		linter   string
+		output   string
+		expected Issue
+	}{
+		{
+			linter: "dupl",
+			output: "a.go:3-17: duplicate of b.go:19-33",
+			expected: Issue{
+				Line: 3, EndLine: 17, Message: "duplicate of b.go:19-33",
+				Related: []Location{{Path: newIssuePath(tmpdir, "b.go"), Line: 19, EndLine: 33}},
+			},
+		},
+		{
+			linter: "vetshadow",
+			output: `a.go:12:3: declaration of "err" shadows declaration at line 8`,
+			expected: Issue{
+				Line: 12, Col: 3, Message: `declaration of "err" shadows declaration at line 8`,
+				Related: []Location{{Path: newIssuePath(tmpdir, "a.go"), Line: 8}},
+			},
+		},
+		{
+			linter:   "vetshadow",
+			output:   "a.go:12: unreachable code",
+			expected: Issue{Line: 12, Message: "unreachable code"},
+		},
+	}
+	for _, testcase := range testcases {
+		linter, err := NewLinter(testcase.linter, defaultLinters[testcase.linter])
+		require.NoError(t, err)
+		issues := make(chan *Issue, 10)
+		state := &linterState{Linter: linter, issues: issues, vars: Vars{}}
+		newOutputParser(func(string, ...interface{}) {}, state, "").parse([]byte(testcase.output))
+		close(issues)
+
+		issue := <-issues
+		require.NotNil(t, issue, testcase.output)
+		assert.Equal(t, "a.go", issue.Path.Relative(), testcase.output)
+		assert.Equal(t, testcase.expected.Line, issue.Line, testcase.output)
+		assert.Equal(t, testcase.expected.Col, issue.Col, testcase.output)
+		assert.Equal(t, testcase.expected.EndLine, issue.EndLine, testcase.output)
+		assert.Equal(t, testcase.expected.Message, issue.Message, testcase.output)
+		require.Len(t, issue.Related, len(testcase.expected.Related), testcase.output)
+		for i, related := range testcase.expected.Related {
+			assert.Equal(t, related.Path.Relative(), issue.Related[i].Path.Relative(), testcase.output)
+			assert.Equal(t, related.Line, issue.Related[i].Line, testcase.output)
+			assert.Equal(t, related.EndLine, issue.Related[i].EndLine, testcase.output)
+		}
+	}
+}
+
+func TestRunLintersFailFastWaitsForNolintReport(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+	config.Deadline = jsonDuration(time.Minute)
+	config.FailFast = true
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkFile(t, tmpdir, "a.go", "package foo\n\n// nolint: vet\nfunc a() {}\n")
+	config.NolintReport = filepath.Join(tmpdir, "report.txt")
+
+	linter, err := NewLinter("custom", LinterConfig{
+		Command:           `sh -c "echo a.go:1: bad"`,
+		Pattern:           "PATH:LINE:MESSAGE",
+		PartitionStrategy: partitionPathsAsDirectories,
+	})
+	require.NoError(t, err)
+	issues, errch := runLinters(map[string]*Linter{"custom": linter}, []string{"."}, 1, nil, nil)
+	for range issues {
+	}
+	for range errch {
+	}
+
+	data, err := ioutil.ReadFile(config.NolintReport)
+	require.NoError(t, err)
+	assert.Equal(t, "a.go:3:1: nolint:vet (no reason) [unmatched]\n", string(data))
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	githubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// githubWorkflowCommand formats an issue as a GitHub Actions workflow command,
+// which GitHub shows as an annotation on the file.
+func githubWorkflowCommand(issue *Issue) string {
+	level := "warning"
+	if issue.Severity == Error {
+		level = "error"
+	}
+	properties := []string{
+		"file=" + githubPropertyEscaper.Replace(issue.Path.Relative()),
+		fmt.Sprintf("line=%d", issue.Line),
+	}
+	if issue.Col != 0 {
+		properties = append(properties, fmt.Sprintf("col=%d", issue.Col))
+	}
+	if issue.EndLine > issue.Line {
+		properties = append(properties, fmt.Sprintf("endLine=%d", issue.EndLine))
+	}
+	if issue.EndCol != 0 {
+		properties = append(properties, fmt.Sprintf("endColumn=%d", issue.EndCol))
+	}
+	properties = append(properties, "title="+githubPropertyEscaper.Replace(issue.Linter))
+	return fmt.Sprintf("::%s %s::%s", level, strings.Join(properties, ","), githubDataEscaper.Replace(issue.Message))
+}
+
+func outputToGitHubActions(w io.Writer, issues chan *Issue) {
+	for issue := range issues {
+		fmt.Fprintln(w, githubWorkflowCommand(issue))
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitHubWorkflowCommand(t *testing.T) {
+	var testcases = []struct {
+		issue    Issue
+		expected string
+	}{
+		{
+			issue:    Issue{Linter: "vet", Severity: Error, Path: newIssuePath("", "a.go"), Line: 3, Col: 2, Message: "unreachable code"},
+			expected: "::error file=a.go,line=3,col=2,title=vet::unreachable code",
+		},
+		{
+			issue:    Issue{Linter: "golint, vet", Severity: Warning, Path: newIssuePath("", "a,b:c.go"), Line: 1, EndLine: 4, Message: "100% bad\nreally"},
+			expected: "::warning file=a%2Cb%3Ac.go,line=1,endLine=4,title=golint%2C vet::100%25 bad%0Areally",
+		},
+	}
+	for _, testcase := range testcases {
+		assert.Equal(t, testcase.expected, githubWorkflowCommand(&testcase.issue))
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Lines of source shown either side of an issue in the HTML report.
+const htmlSnippetContext = 3
+
+type htmlReport struct {
+	Generated string
+	Total     int
+	Charts    []htmlChart
+	Issues    []*htmlIssue
+}
+
+type htmlChart struct {
+	Title  string
+	Counts []*htmlCount
+}
+
+type htmlCount struct {
+	Name  string
+	Count int
+	// Width of the bar as a percentage of the largest count.
+	Width int
+}
+
+type htmlIssue struct {
+	*Issue
+	Directory string
+	Snippet   []htmlSourceLine
+}
+
+type htmlSourceLine struct {
+	Number  int
+	Text    string
+	Flagged bool
+}
+
+// countIssues counts issues by key, ordered by decreasing count then name.
+func countIssues(issues []*htmlIssue, key func(*htmlIssue) string) []*htmlCount {
+	counts := map[string]*htmlCount{}
+	out := []*htmlCount{}
+	for _, issue := range issues {
+		name := key(issue)
+		if counts[name] == nil {
+			counts[name] = &htmlCount{Name: name}
+			out = append(out, counts[name])
+		}
+		counts[name].Count++
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	for _, count := range out {
+		count.Width = count.Count * 100 / out[0].Count
+	}
+	return out
+}
+
+func newHTMLReport(issues []*Issue, now time.Time) *htmlReport {
+	lines := sourceLines{}
+	report := &htmlReport{Generated: now.Format(time.RFC1123), Total: len(issues)}
+	for _, issue := range issues {
+		report.Issues = append(report.Issues, &htmlIssue{
+			Issue:     issue,
+			Directory: filepath.Dir(issue.Path.Relative()),
+			Snippet:   sourceSnippet(lines, issue),
+		})
+	}
+	report.Charts = []htmlChart{
+		{Title: "linter", Counts: countIssues(report.Issues, func(i *htmlIssue) string { return i.Linter })},
+		{Title: "severity", Counts: countIssues(report.Issues, func(i *htmlIssue) string { return string(i.Severity) })},
+		{Title: "directory", Counts: countIssues(report.Issues, func(i *htmlIssue) string { return i.Directory })},
+	}
+	return report
+}
+
+// sourceSnippet returns the lines of source covered by an issue, with some
+// context either side.
+func sourceSnippet(lines sourceLines, issue *Issue) []htmlSourceLine {
+	source := lines.file(issue.Path.Relative())
+	snippet := []htmlSourceLine{}
+	for n := issue.Line - htmlSnippetContext; n <= issue.LastLine()+htmlSnippetContext; n++ {
+		if n < 1 || n > len(source) {
+			continue
+		}
+		snippet = append(snippet, htmlSourceLine{
+			Number:  n,
+			Text:    source[n-1],
+			Flagged: n >= issue.Line && n <= issue.LastLine(),
+		})
+	}
+	return snippet
+}
+
+func outputToHTML(w io.Writer, issues chan *Issue) {
+	collected := []*Issue{}
+	for issue := range issues {
+		collected = append(collected, issue)
+	}
+	if err := htmlReportTemplate.Execute(w, newHTMLReport(collected, time.Now())); err != nil {
+		warning("failed to write HTML report: %s", err)
+	}
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>gometalinter report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.1em; }
+.charts { display: flex; flex-wrap: wrap; }
+.chart { margin-right: 3em; }
+.chart table { border-collapse: collapse; }
+.chart td { padding: 2px 6px; }
+.chart .bar { background: #4a7bd0; height: 1em; min-width: 2px; }
+.chart .track { width: 12em; }
+#filter { margin: 1em 0; width: 30em; }
+#issues { border-collapse: collapse; width: 100%; }
+#issues th { cursor: pointer; text-align: left; background: #eee; }
+#issues th, #issues td { padding: 4px 8px; border-bottom: 1px solid #ddd; vertical-align: top; }
+.error { color: #b00; }
+.warning { color: #a60; }
+pre { margin: 4px 0; background: #f6f6f6; }
+.line { display: block; }
+.flagged { background: #ffe58a; }
+.lineno { color: #999; display: inline-block; width: 4em; }
+</style>
+</head>
+<body>
+<h1>gometalinter report</h1>
+<p>{{.Total}} issues, generated {{.Generated}}.</p>
+<div class="charts">
+{{range .Charts}}<div class="chart">
+<h2>By {{.Title}}</h2>
+<table>
+{{range .Counts}}<tr><td>{{.Name}}</td><td class="track"><div class="bar" style="width: {{.Width}}%"></div></td><td>{{.Count}}</td></tr>
+{{end}}</table>
+</div>
+{{end}}</div>
+<input id="filter" type="search" placeholder="Filter issues">
+<table id="issues">
+<thead>
+<tr><th data-type="text">Path</th><th data-type="number">Line</th><th data-type="text">Severity</th><th data-type="text">Linter</th><th data-type="text">Message</th></tr>
+</thead>
+<tbody>
+{{range .Issues}}<tr>
+<td>{{.Path}}</td>
+<td>{{.Line}}</td>
+<td class="{{.Severity}}">{{.Severity}}</td>
+<td>{{.Linter}}</td>
+<td>{{if .Snippet}}<details><summary>{{.Message}}</summary>
+<pre>{{range .Snippet}}<span class="line{{if .Flagged}} flagged{{end}}"><span class="lineno">{{.Number}}</span>{{.Text}}</span>{{end}}</pre>
+</details>{{else}}{{.Message}}{{end}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+<script>
+(function() {
+  var table = document.getElementById("issues");
+  var body = table.tBodies[0];
+  var rows = Array.prototype.slice.call(body.rows);
+  document.getElementById("filter").addEventListener("input", function(e) {
+    var terms = e.target.value.toLowerCase().split(/\s+/);
+    rows.forEach(function(row) {
+      var text = row.textContent.toLowerCase();
+      row.style.display = terms.every(function(term) { return text.indexOf(term) >= 0; }) ? "" : "none";
+    });
+  });
+  var headers = table.tHead.rows[0].cells;
+  Array.prototype.forEach.call(headers, function(header, column) {
+    var ascending = true;
+    header.addEventListener("click", function() {
+      var numeric = header.getAttribute("data-type") === "number";
+      rows.sort(function(a, b) {
+        var x = a.cells[column].textContent, y = b.cells[column].textContent;
+        var order = numeric ? parseInt(x, 10) - parseInt(y, 10) : x.localeCompare(y);
+        return ascending ? order : -order;
+      });
+      ascending = !ascending;
+      rows.forEach(function(row) { body.appendChild(row); });
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLReport(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkDir(t, tmpdir, "sub")
+	mkFile(t, tmpdir, "a.go", "package foo\n\nfunc a() {\n\t<-x\n}\n")
+
+	issues := []*Issue{
+		{Linter: "vet", Severity: Error, Path: newIssuePath(tmpdir, "a.go"), Line: 4, Message: "bad <channel>"},
+		{Linter: "golint", Severity: Warning, Path: newIssuePath(tmpdir, "a.go"), Line: 1, Message: "comment"},
+		{Linter: "golint", Severity: Warning, Path: newIssuePath(tmpdir, "sub/file.go"), Line: 1, Message: "comment"},
+	}
+	report := newHTMLReport(issues, time.Now())
+	require.Len(t, report.Charts, 3)
+	assert.Equal(t, []*htmlCount{{Name: "golint", Count: 2, Width: 100}, {Name: "vet", Count: 1, Width: 50}}, report.Charts[0].Counts)
+	assert.Equal(t, []*htmlCount{{Name: ".", Count: 2, Width: 100}, {Name: "sub", Count: 1, Width: 50}}, report.Charts[2].Counts)
+	assert.Equal(t, []htmlSourceLine{
+		{Number: 1, Text: "package foo"},
+		{Number: 2},
+		{Number: 3, Text: "func a() {"},
+		{Number: 4, Text: "\t<-x", Flagged: true},
+		{Number: 5, Text: "}"},
+	}, report.Issues[0].Snippet)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, htmlReportTemplate.Execute(buf, report))
+	html := buf.String()
+	assert.Contains(t, html, "bad &lt;channel&gt;")
+	assert.Contains(t, html, `<span class="line flagged"><span class="lineno">4</span>	&lt;-x</span>`)
+	assert.False(t, strings.Contains(html, "http://") || strings.Contains(html, "https://"), "report must not load external resources")
+}
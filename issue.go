@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"text/template"
@@ -69,16 +70,108 @@ func newIssuePathFromAbsPath(root, path string) (IssuePath, error) {
 	return newIssuePath(resolvedRoot, relPath), err
 }
 
+// Location of source code related to an issue, eg. the other half of a
+// duplicate code pair.
+type Location struct {
+	Path    IssuePath `json:"path"`
+	Line    int       `json:"line"`
+	Col     int       `json:"col,omitempty"`
+	EndLine int       `json:"end_line,omitempty"`
+}
+
+func (l Location) String() string {
+	s := fmt.Sprintf("%s:%d", l.Path.Relative(), l.Line)
+	if l.EndLine > l.Line {
+		s += fmt.Sprintf("-%d", l.EndLine)
+	}
+	if l.Col != 0 {
+		s += fmt.Sprintf(":%d", l.Col)
+	}
+	return s
+}
+
+func joinLocations(locations []Location) string {
+	parts := make([]string, 0, len(locations))
+	for _, location := range locations {
+		parts = append(parts, location.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
 type Issue struct {
-	Linter     string    `json:"linter"`
-	Severity   Severity  `json:"severity"`
-	Path       IssuePath `json:"path"`
-	Line       int       `json:"line"`
-	Col        int       `json:"col"`
-	Message    string    `json:"message"`
+	Linter   string    `json:"linter"`
+	Severity Severity  `json:"severity"`
+	Path     IssuePath `json:"path"`
+	Line     int       `json:"line"`
+	Col      int       `json:"col"`
+	// End of the issue's range, if the linter reports one.
+	EndLine int    `json:"end_line,omitempty"`
+	EndCol  int    `json:"end_col,omitempty"`
+	Message string `json:"message"`
+	// Other locations involved in the issue.
+	Related []Location `json:"related,omitempty"`
+	// All linters that reported the issue, if it was aggregated.
+	Linters []string `json:"linters"`
+	// Messages from the other linters that reported the issue, if it was
+	// aggregated by position.
+	Secondary []SecondaryMessage `json:"secondary,omitempty"`
+	// Number of later issues from the same linter that were suppressed by
+	// --max-issues-per-linter or --max-same-issues.
+	Suppressed int `json:"suppressed,omitempty"`
 	formatTmpl *template.Template
 }
 
+// SecondaryMessage is an issue reported at the same position as a primary
+// issue, and aggregated into it.
+type SecondaryMessage struct {
+	Linter   string   `json:"linter"`
+	Severity Severity `json:"severity"`
+	Line     int      `json:"line"`
+	Col      int      `json:"col"`
+	Message  string   `json:"message"`
+}
+
+// SecondaryIssues returns the secondary messages of an aggregated issue as
+// issues in the same file, formatted in the same way.
+func (i *Issue) SecondaryIssues() []*Issue {
+	out := make([]*Issue, 0, len(i.Secondary))
+	for _, s := range i.Secondary {
+		out = append(out, &Issue{
+			Linter:     s.Linter,
+			Severity:   s.Severity,
+			Path:       i.Path,
+			Line:       s.Line,
+			Col:        s.Col,
+			Message:    s.Message,
+			formatTmpl: i.formatTmpl,
+		})
+	}
+	return out
+}
+
+// MarshalJSON encodes the issue, replacing the comma separated linter names
+// of an aggregated issue with the name of the primary linter. All the linter
+// names are in "linters", which is set whether or not the issue was
+// aggregated.
+func (i *Issue) MarshalJSON() ([]byte, error) {
+	type plainIssue Issue
+	issue := *i
+	issue.Linters = i.LinterNames()
+	out := struct {
+		Linter string `json:"linter"`
+		*plainIssue
+	}{issue.Linters[0], (*plainIssue)(&issue)}
+	return json.Marshal(out)
+}
+
+// LinterNames returns the names of all linters that reported the issue.
+func (i *Issue) LinterNames() []string {
+	if len(i.Linters) > 0 {
+		return i.Linters
+	}
+	return []string{i.Linter}
+}
+
 // NewIssue returns a new issue. Returns an error if formatTmpl is not a valid
 // template for an Issue.
 func NewIssue(linter string, formatTmpl *template.Template) (*Issue, error) {
@@ -109,6 +202,38 @@ func (i *Issue) String() string {
 	return buf.String()
 }
 
+// issueRuleRegexp matches a check ID at the end of a message, as reported by
+// staticcheck and related linters, eg. "(SA1019)".
+var issueRuleRegexp = regexp.MustCompile(`\(([A-Z]+[0-9]+)\)$`)
+
+// Rule returns the ID of the check that reported the issue, if known.
+func (i *Issue) Rule() string {
+	if match := issueRuleRegexp.FindStringSubmatch(strings.TrimSpace(i.Message)); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// LastLine returns the last line covered by the issue.
+func (i *Issue) LastLine() int {
+	if i.EndLine > i.Line {
+		return i.EndLine
+	}
+	return i.Line
+}
+
+// UnmentionedRelated returns the related locations that do not already appear
+// in the issue's message.
+func (i *Issue) UnmentionedRelated() []Location {
+	out := []Location{}
+	for _, location := range i.Related {
+		if !strings.Contains(i.Message, location.String()) {
+			out = append(out, location)
+		}
+	}
+	return out
+}
+
 type sortedIssues struct {
 	issues []*Issue
 	order  []string
@@ -164,3 +289,112 @@ func SortIssueChan(issues chan *Issue, order []string) chan *Issue {
 	}()
 	return out
 }
+
+// normalisedMessageRegexp matches the parts of a message that vary between
+// otherwise identical issues: quoted text and numbers.
+var normalisedMessageRegexp = regexp.MustCompile("\"[^\"]*\"|`[^`]*`|\\d+")
+
+func normaliseMessage(message string) string {
+	return normalisedMessageRegexp.ReplaceAllStringFunc(message, func(s string) string {
+		switch s[0] {
+		case '"', '`':
+			return s[:1] + s[:1]
+		}
+		return "0"
+	})
+}
+
+type sameIssueKey struct {
+	linter  string
+	message string
+}
+
+// LimitIssueChan reads issues from one channel and returns at most maxPerLinter
+// issues from each linter, and at most maxSame issues with the same linter and
+// normalised message, to another. Limits of 0 are ignored. Issues are passed
+// on as they are read, except for the last issue kept from each linter, which
+// is held back until all issues have been read so that it can record how many
+// were suppressed. A summary is then printed.
+func LimitIssueChan(issues chan *Issue, maxPerLinter, maxSame int) chan *Issue {
+	out := make(chan *Issue, 1000000)
+	go func() {
+		limiter := newIssueLimiter(maxPerLinter, maxSame)
+		for issue := range issues {
+			if ready := limiter.add(issue); ready != nil {
+				out <- ready
+			}
+		}
+		for _, issue := range limiter.finish() {
+			out <- issue
+		}
+		for _, message := range limiter.warnings() {
+			warning("%s", message)
+		}
+		close(out)
+	}()
+	return out
+}
+
+type issueLimiter struct {
+	maxPerLinter int
+	maxSame      int
+	kept         map[string]int
+	same         map[sameIssueKey]int
+	suppressed   map[string]int
+	// The last issue kept from each linter, in the order linters were seen.
+	last    map[string]*Issue
+	linters []string
+}
+
+func newIssueLimiter(maxPerLinter, maxSame int) *issueLimiter {
+	return &issueLimiter{
+		maxPerLinter: maxPerLinter,
+		maxSame:      maxSame,
+		kept:         map[string]int{},
+		same:         map[sameIssueKey]int{},
+		suppressed:   map[string]int{},
+		last:         map[string]*Issue{},
+	}
+}
+
+// add records an issue, and returns the previously held back issue from the
+// same linter if the new issue is kept.
+func (l *issueLimiter) add(issue *Issue) *Issue {
+	key := sameIssueKey{linter: issue.Linter, message: normaliseMessage(issue.Message)}
+	l.same[key]++
+	if (l.maxPerLinter > 0 && l.kept[issue.Linter] >= l.maxPerLinter) || (l.maxSame > 0 && l.same[key] > l.maxSame) {
+		l.suppressed[issue.Linter]++
+		stats.suppressed(suppressedByLimits, 1)
+		return nil
+	}
+	l.kept[issue.Linter]++
+	previous, ok := l.last[issue.Linter]
+	if !ok {
+		l.linters = append(l.linters, issue.Linter)
+	}
+	l.last[issue.Linter] = issue
+	return previous
+}
+
+// finish returns the issues held back by add, each recording the number of
+// issues suppressed from its linter.
+func (l *issueLimiter) finish() []*Issue {
+	out := make([]*Issue, 0, len(l.linters))
+	for _, linter := range l.linters {
+		issue := l.last[linter]
+		issue.Suppressed = l.suppressed[linter]
+		out = append(out, issue)
+	}
+	return out
+}
+
+// warnings returns a message for each linter with suppressed issues.
+func (l *issueLimiter) warnings() []string {
+	out := []string{}
+	for _, linter := range l.linters {
+		if suppressed := l.suppressed[linter]; suppressed > 0 {
+			out = append(out, fmt.Sprintf("%d more issues from %s suppressed (see --max-issues-per-linter and --max-same-issues)", suppressed, linter))
+		}
+	}
+	return out
+}
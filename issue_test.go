@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"sort"
 	"testing"
 
@@ -37,3 +39,67 @@ func TestCompareOrderWithMessage(t *testing.T) {
 	assert.True(t, CompareIssue(issueM, issueU, order))
 	assert.False(t, CompareIssue(issueU, issueM, order))
 }
+
+func TestIssueRelatedLocations(t *testing.T) {
+	issue := &Issue{
+		Line:    3,
+		Message: "duplicate of b.go:19-33",
+		Related: []Location{
+			{Path: newIssuePath("", "b.go"), Line: 19, EndLine: 33},
+			{Path: newIssuePath("", "c.go"), Line: 4, Col: 2},
+		},
+	}
+	assert.Equal(t, 3, issue.LastLine())
+	assert.Equal(t, []Location{issue.Related[1]}, issue.UnmentionedRelated())
+	assert.Equal(t, "b.go:19-33, c.go:4:2", joinLocations(issue.Related))
+
+	data, err := json.Marshal(issue)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"related":[{"path":"b.go","line":19,"end_line":33},{"path":"c.go","line":4,"col":2}]`)
+}
+
+func TestNormaliseMessage(t *testing.T) {
+	assert.Equal(t, `"" is a misspelling of ""`, normaliseMessage(`"teh" is a misspelling of "the"`))
+	assert.Equal(t, "line is 0 characters", normaliseMessage("line is 120 characters"))
+	assert.Equal(t, "don't use `` here", normaliseMessage("don't use `x` here"))
+}
+
+func TestLimitIssueChan(t *testing.T) {
+	in := make(chan *Issue, 10)
+	for _, word := range []string{"teh", "recieve", "seperate", "untill"} {
+		in <- &Issue{Linter: "misspell", Message: fmt.Sprintf("%q is a misspelling", word)}
+	}
+	in <- &Issue{Linter: "misspell", Message: "other"}
+	in <- &Issue{Linter: "lll", Message: "line is 120 characters"}
+	in <- &Issue{Linter: "lll", Message: "line is 130 characters"}
+	in <- &Issue{Linter: "lll", Message: "line is 140 characters"}
+	close(in)
+
+	out := []string{}
+	suppressed := map[string]int{}
+	for issue := range LimitIssueChan(in, 3, 2) {
+		out = append(out, issue.Message)
+		suppressed[issue.Message] = issue.Suppressed
+	}
+	assert.Equal(t, []string{`"teh" is a misspelling`, `"recieve" is a misspelling`, "line is 120 characters", "other", "line is 130 characters"}, out)
+	assert.Equal(t, map[string]int{
+		`"teh" is a misspelling`:     0,
+		`"recieve" is a misspelling`: 0,
+		"other":                      2,
+		"line is 120 characters":     0,
+		"line is 130 characters":     1,
+	}, suppressed)
+}
+
+func TestIssueLimiterWarnings(t *testing.T) {
+	limiter := newIssueLimiter(0, 1)
+	for _, message := range []string{"a 1", "a 2", "a 3", "b"} {
+		limiter.add(&Issue{Linter: "lll", Message: message})
+	}
+	limiter.add(&Issue{Linter: "vet", Message: "c"})
+	issues := limiter.finish()
+	require.Len(t, issues, 2)
+	assert.Equal(t, "b", issues[0].Message)
+	assert.Equal(t, 2, issues[0].Suppressed)
+	assert.Equal(t, []string{"2 more issues from lll suppressed (see --max-issues-per-linter and --max-same-issues)"}, limiter.warnings())
+}
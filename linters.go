@@ -18,14 +18,39 @@ type LinterConfig struct {
 	PartitionStrategy partitionStrategy
 	IsFast            bool
 	defaultEnabled    bool
+
+	// Which output streams are parsed for issues: "stdout", "stderr" or
+	// "both" (the default). Output that is not parsed is reported if the
+	// linter fails.
+	Streams string
+	// Lines on stderr matching ErrorPattern are reported as linter errors
+	// rather than issues.
+	ErrorPattern string
+	// GOMAXPROCS for the linter. If zero, a value is allocated from the CPUs
+	// that are free when the linter starts.
+	MaxProcs int
+	// Environment variables set for the linter, in addition to those
+	// gometalinter runs with. Values are expanded as for Command.
+	Env map[string]string
+	// Directory to run the linter from. Paths passed to the linter are
+	// rewritten to be relative to it.
+	WorkDir string
 }
 
 type Linter struct {
 	LinterConfig
-	Name  string
-	regex *regexp.Regexp
+	Name       string
+	regex      *regexp.Regexp
+	errorRegex *regexp.Regexp
 }
 
+// Linter output streams.
+const (
+	stdoutStream = "stdout"
+	stderrStream = "stderr"
+	bothStreams  = "both"
+)
+
 // NewLinter returns a new linter from a config
 func NewLinter(name string, config LinterConfig) (*Linter, error) {
 	if p, ok := predefinedPatterns[config.Pattern]; ok {
@@ -35,6 +60,20 @@ func NewLinter(name string, config LinterConfig) (*Linter, error) {
 	if err != nil {
 		return nil, err
 	}
+	var errorRegex *regexp.Regexp
+	if config.ErrorPattern != "" {
+		errorRegex, err = regexp.Compile(config.ErrorPattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch config.Streams {
+	case "":
+		config.Streams = bothStreams
+	case stdoutStream, stderrStream, bothStreams:
+	default:
+		return nil, fmt.Errorf("unknown output stream %q for linter %s", config.Streams, name)
+	}
 	if config.PartitionStrategy == nil {
 		config.PartitionStrategy = partitionPathsAsDirectories
 	}
@@ -42,6 +81,7 @@ func NewLinter(name string, config LinterConfig) (*Linter, error) {
 		LinterConfig: config,
 		Name:         name,
 		regex:        regex,
+		errorRegex:   errorRegex,
 	}, nil
 }
 
@@ -49,6 +89,17 @@ func (l *Linter) String() string {
 	return l.Name
 }
 
+// parsesStream returns true if issues should be parsed from the given stream.
+func (l *Linter) parsesStream(stream string) bool {
+	return l.Streams == bothStreams || l.Streams == stream
+}
+
+// multiLine returns true if the linter pattern can match across lines, in
+// which case the output must be parsed in full rather than line by line.
+func (l *Linter) multiLine() bool {
+	return strings.Contains(l.Pattern, `\n`) || strings.Contains(l.Pattern, "(?s")
+}
+
 var predefinedPatterns = map[string]string{
 	"PATH:LINE:COL:MESSAGE": `^(?P<path>.*?\.go):(?P<line>\d+):(?P<col>\d+):\s*(?P<message>.*)$`,
 	"PATH:LINE:MESSAGE":     `^(?P<path>.*?\.go):(?P<line>\d+):\s*(?P<message>.*)$`,
@@ -71,6 +122,21 @@ func getLinterByName(name string, overrideConf LinterConfig) *Linter {
 	if val := overrideConf.PartitionStrategy; val != nil {
 		conf.PartitionStrategy = val
 	}
+	if val := overrideConf.Streams; val != "" {
+		conf.Streams = val
+	}
+	if val := overrideConf.ErrorPattern; val != "" {
+		conf.ErrorPattern = val
+	}
+	if val := overrideConf.MaxProcs; val != 0 {
+		conf.MaxProcs = val
+	}
+	if val := overrideConf.Env; len(val) > 0 {
+		conf.Env = val
+	}
+	if val := overrideConf.WorkDir; val != "" {
+		conf.WorkDir = val
+	}
 
 	linter, _ := NewLinter(name, conf)
 	return linter
@@ -200,6 +266,66 @@ func validateLinters(linters map[string]*Linter, config *Config) error {
 	return nil
 }
 
+// isKnownLinter returns true if name is a default or custom linter.
+func isKnownLinter(name string) bool {
+	if _, isDefault := defaultLinters[name]; isDefault {
+		return true
+	}
+	_, isCustom := config.Linters[name]
+	return isCustom
+}
+
+// suggestLinter returns the known linter closest to name by edit distance, or
+// "" if none are close enough to be a likely typo.
+func suggestLinter(name string) string {
+	names := []string{}
+	for linter := range defaultLinters {
+		names = append(names, linter)
+	}
+	for linter := range config.Linters {
+		names = append(names, linter)
+	}
+	sort.Strings(names)
+	best, bestDistance := "", len(name)/3+1
+	for _, linter := range names {
+		if distance := editDistance(name, linter); distance <= bestDistance && (best == "" || distance < bestDistance) {
+			best, bestDistance = linter, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// vetShadowPattern matches "go vet --shadow" output, capturing the shadowed
+// declaration as a related location.
+const vetShadowPattern = `^(?:vet:.*?\.go:\s+)?(?P<path>.*?\.go):(?P<line>\d+):(?:(?P<col>\d+):)?\s*(?P<message>declaration of .*? shadows declaration at (?:line |(?P<related_path>.*?\.go):)(?P<related_line>\d+).*|.*)$`
+
 const vetPattern = `^(?:vet:.*?\.go:\s+(?P<path>.*?\.go):(?P<line>\d+):(?P<col>\d+):\s*(?P<message>.*))|((?P<path>.*?\.go):(?P<line>\d+):(?P<col>\d+):\s*(?P<message>.*))|(?:(?P<path>.*?\.go):(?P<line>\d+):\s*(?P<message>.*))$`
 
 var defaultLinters = map[string]LinterConfig{
@@ -219,7 +345,7 @@ var defaultLinters = map[string]LinterConfig{
 	},
 	"dupl": {
 		Command:           `dupl -plumbing -threshold {duplthreshold}`,
-		Pattern:           `^(?P<path>.*?\.go):(?P<line>\d+)-\d+:\s*(?P<message>.*)$`,
+		Pattern:           `^(?P<path>.*?\.go):(?P<line>\d+)-(?P<end_line>\d+):\s*(?P<message>duplicate of (?P<related_path>.*?\.go):(?P<related_line>\d+)-(?P<related_end_line>\d+)|.*)$`,
 		InstallFrom:       "github.com/mibk/dupl",
 		PartitionStrategy: partitionPathsAsFiles,
 		IsFast:            true,
@@ -402,7 +528,7 @@ var defaultLinters = map[string]LinterConfig{
 	},
 	"vetshadow": {
 		Command:           `go vet --shadow`,
-		Pattern:           vetPattern,
+		Pattern:           vetShadowPattern,
 		PartitionStrategy: partitionPathsAsPackages,
 		defaultEnabled:    true,
 		IsFast:            true,
@@ -174,3 +174,40 @@ FAIL	test	0.003s`
 func functionName(i interface{}) string {
 	return runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name()
 }
+
+func TestLinterMultiLine(t *testing.T) {
+	assert.True(t, getLinterByName("testify", LinterConfig{}).multiLine())
+	assert.False(t, getLinterByName("test", LinterConfig{}).multiLine())
+	assert.False(t, getLinterByName("dupl", LinterConfig{}).multiLine())
+	assert.False(t, getLinterByName("vet", LinterConfig{}).multiLine())
+}
+
+func TestNewLinterStreams(t *testing.T) {
+	linter, err := NewLinter("custom", LinterConfig{Pattern: "path"})
+	require.NoError(t, err)
+	assert.True(t, linter.parsesStream(stdoutStream))
+	assert.True(t, linter.parsesStream(stderrStream))
+
+	linter, err = NewLinter("custom", LinterConfig{Pattern: "path", Streams: "stderr"})
+	require.NoError(t, err)
+	assert.False(t, linter.parsesStream(stdoutStream))
+	assert.True(t, linter.parsesStream(stderrStream))
+
+	_, err = NewLinter("custom", LinterConfig{Pattern: "path", Streams: "stdin"})
+	require.Error(t, err)
+}
+
+func TestSuggestLinter(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.Linters = map[string]StringOrLinterConfig{"mylinter": {}}
+
+	assert.True(t, isKnownLinter("errcheck"))
+	assert.True(t, isKnownLinter("mylinter"))
+	assert.False(t, isKnownLinter("errchek"))
+	assert.Equal(t, "errcheck", suggestLinter("errchek"))
+	assert.Equal(t, "gocyclo", suggestLinter("gocylco"))
+	assert.Equal(t, "mylinter", suggestLinter("mylintr"))
+	assert.Equal(t, "", suggestLinter("something"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+}
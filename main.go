@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/user"
@@ -15,6 +16,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/alecthomas/units"
 	kingpin "gopkg.in/alecthomas/kingpin.v3-unstable"
 )
 
@@ -54,6 +56,9 @@ func setupFlags(app *kingpin.Application) {
 	app.Flag("exclude", "Exclude messages matching these regular expressions.").Short('e').PlaceHolder("REGEXP").StringsVar(&config.Exclude)
 	app.Flag("include", "Include messages matching these regular expressions.").Short('I').PlaceHolder("REGEXP").StringsVar(&config.Include)
 	app.Flag("skip", "Skip directories with this name when expanding '...'.").Short('s').PlaceHolder("DIR...").StringsVar(&config.Skip)
+	app.Flag("include-generated", "Include issues in generated files.").BoolVar(&config.IncludeGenerated)
+	app.Flag("generated-header", "Treat files with a comment matching this regular expression before the package clause as generated.").PlaceHolder("REGEXP").StringsVar(&config.GeneratedHeaders)
+	app.Flag("generated-path", "Treat files with a path or name matching this glob as generated.").PlaceHolder("GLOB").StringsVar(&config.GeneratedPaths)
 	app.Flag("vendor", "Enable vendoring support (skips 'vendor' directories and sets GO15VENDOREXPERIMENT=1).").BoolVar(&config.Vendor)
 	app.Flag("cyclo-over", "Report functions with cyclomatic complexity over N (using gocyclo).").PlaceHolder("10").IntVar(&config.Cyclo)
 	app.Flag("line-length", "Report lines longer than N (using lll).").PlaceHolder("80").IntVar(&config.LineLength)
@@ -65,12 +70,33 @@ func setupFlags(app *kingpin.Application) {
 	app.Flag("sort", fmt.Sprintf("Sort output by any of %s.", strings.Join(sortKeys, ", "))).PlaceHolder("none").EnumsVar(&config.Sort, sortKeys...)
 	app.Flag("tests", "Include test files for linters that support this option.").Short('t').BoolVar(&config.Test)
 	app.Flag("deadline", "Cancel linters if they have not completed within this duration.").PlaceHolder("30s").DurationVar((*time.Duration)(&config.Deadline))
+	app.Flag("max-linter-output", "Discard output from each linter invocation, stdout and stderr combined, beyond this size (0 for no limit).").PlaceHolder("0").BytesVar((*units.Base2Bytes)(&config.MaxLinterOutput))
 	app.Flag("errors", "Only show errors.").BoolVar(&config.Errors)
 	app.Flag("json", "Generate structured JSON rather than standard line-based output.").BoolVar(&config.JSON)
+	app.Flag("ndjson", "Stream issues and linter progress events as newline-delimited JSON.").BoolVar(&config.NDJSON)
+	app.Flag("github-actions", "Generate GitHub Actions workflow commands, which annotate the code in pull requests.").BoolVar(&config.GitHubActions)
+	app.Flag("code-climate", "Generate a Code Climate JSON report, as used by GitLab Code Quality.").BoolVar(&config.CodeClimate)
+	app.Flag("html", "Also write a self-contained HTML report of the issues to FILE.").PlaceHolder("FILE").StringVar(&config.HTML)
+	app.Flag("color", "Colorize console output and group it by file: auto (when writing to a terminal and NO_COLOR is not set), always or never.").PlaceHolder(colorAuto).EnumVar(&config.Color, colorAuto, colorAlways, colorNever)
+	app.Flag("summary", "Print a summary of the run after console output.").BoolVar(&config.Summary)
+	app.Flag("json-envelope", `Wrap JSON output in an object with the issues and statistics about the run: {"issues": [...], "stats": {...}}.`).BoolVar(&config.JSONEnvelope)
+	app.Flag("out", fmt.Sprintf("Write issues in FORMAT to FILE, or to stdout if no FILE is given. May be repeated. FORMAT is one of %s.", strings.Join(outputFormatNames(), ", "))).PlaceHolder("FORMAT[:FILE]").StringsVar(&config.Out)
 	app.Flag("checkstyle", "Generate checkstyle XML rather than standard line-based output.").BoolVar(&config.Checkstyle)
 	app.Flag("enable-gc", "Enable GC for linters (useful on large repositories).").BoolVar(&config.EnableGC)
 	app.Flag("aggregate", "Aggregate issues reported by several linters.").BoolVar(&config.Aggregate)
+	app.Flag("aggregate-by", "Aggregate issues with the same message, or at the same position.").PlaceHolder("message").EnumVar(&config.AggregateBy, aggregateByMessage, aggregateByPosition)
+	app.Flag("aggregate-window", "Aggregate issues starting within N lines of each other when aggregating by position.").PlaceHolder("0").IntVar(&config.AggregateWindow)
+	app.Flag("linter-priority", "Linters whose message is preferred when aggregating by position, highest priority first.").PlaceHolder("LINTER").StringsVar(&config.LinterPriority)
+	app.Flag("max-issues-per-linter", "Report at most N issues from each linter (0 for no limit).").PlaceHolder("0").IntVar(&config.MaxIssuesPerLinter)
+	app.Flag("max-same-issues", "Report at most N issues from a linter with the same message, ignoring quoted text and numbers (0 for no limit).").PlaceHolder("0").IntVar(&config.MaxSameIssues)
+	app.Flag("exclude-rules-report", "Print the number of issues excluded by each ExcludeRules entry in the config file.").BoolVar(&config.ExcludeRulesReport)
 	app.Flag("warn-unmatched-nolint", "Warn if a nolint directive is not matched with an issue.").BoolVar(&config.WarnUnmatchedDirective)
+	app.Flag("fix-unmatched-nolint", "Remove linters that did not match an issue from nolint directives, and directives with no linters left.").BoolVar(&config.FixUnmatchedDirective)
+	app.Flag("require-nolint-reason", "Report nolint directives that do not explain themselves with a trailing // comment.").BoolVar(&config.RequireNolintReason)
+	app.Flag("require-nolint-specific", "Report nolint directives that do not name the linters they suppress.").BoolVar(&config.RequireNolintSpecific)
+	app.Flag("nolint-report", "Write a report of all nolint directives to FILE (- for stdout).").PlaceHolder("FILE").StringVar(&config.NolintReport)
+	app.Flag("nolint-report-format", "Format of the nolint report.").PlaceHolder("text").EnumVar(&config.NolintReportFormat, "text", "json")
+	app.Flag("fail-fast", "Cancel all linters and exit after the first issue or linter error.").BoolVar(&config.FailFast)
 	app.GetFlag("help").Short('h')
 }
 
@@ -224,37 +250,38 @@ Severity override map (default is "warning"):
 
 	start := time.Now()
 	paths := resolvePaths(*pathsArg, config.Skip)
+	stats = newRunStats(start)
+	stats.linted(paths)
 
 	linters := lintersFromConfig(config)
 	err := validateLinters(linters, config)
 	kingpin.FatalIfError(err, "")
 
+	outputs, err := outputsFromConfig(config)
+	kingpin.FatalIfError(err, "")
+	kingpin.FatalIfError(openOutputs(outputs), "")
 	issues, errch := runLinters(linters, paths, config.Concurrency, exclude, include)
-	status := 0
-	if config.JSON {
-		status |= outputToJSON(issues)
-	} else if config.Checkstyle {
-		status |= outputToCheckstyle(issues)
-	} else {
-		status |= outputToConsole(issues)
-	}
+	status := writeOutputs(outputs, issues)
 	for err := range errch {
 		warning("%s", err)
 		status |= 2
 	}
+	flushNolintReport()
 	elapsed := time.Since(start)
 	debug("total elapsed time %s", elapsed)
+	if events != nil {
+		events.summary(elapsed, status)
+	}
+	closeOutputs(outputs)
 	os.Exit(status)
 }
 
 // nolint: gocyclo
 func processConfig(config *Config) (include *regexp.Regexp, exclude *regexp.Regexp) {
-	tmpl, err := template.New("output").Parse(config.Format)
+	tmpl, err := template.New("output").Funcs(formatFuncs).Parse(config.Format)
 	kingpin.FatalIfError(err, "invalid format %q", config.Format)
 	config.formatTemplate = tmpl
 
-	// Ensure that gometalinter manages threads, not linters.
-	os.Setenv("GOMAXPROCS", "1")
 	// Force sorting by path if checkstyle mode is selected
 	// !jsonFlag check is required to handle:
 	// 	gometalinter --json --checkstyle --sort=severity
@@ -279,39 +306,56 @@ func processConfig(config *Config) (include *regexp.Regexp, exclude *regexp.Rege
 		include = regexp.MustCompile(strings.Join(config.Include, "|"))
 	}
 
+	now := time.Now()
+	for i, rule := range config.ExcludeRules {
+		kingpin.FatalIfError(rule.compile(now), "invalid exclude rule %d", i+1)
+	}
+
+	config.generatedHeaders = nil
+	for _, header := range config.GeneratedHeaders {
+		re, err := regexp.Compile(header)
+		kingpin.FatalIfError(err, "invalid generated header pattern %q", header)
+		config.generatedHeaders = append(config.generatedHeaders, re)
+	}
+
 	runtime.GOMAXPROCS(config.Concurrency)
 	return include, exclude
 }
 
-func outputToConsole(issues chan *Issue) int {
-	status := 0
+func outputToConsole(w io.Writer, issues chan *Issue) {
 	for issue := range issues {
-		if config.Errors && issue.Severity != Error {
-			continue
+		fmt.Fprintln(w, issue.String())
+		for _, secondary := range issue.SecondaryIssues() {
+			fmt.Fprintf(w, "\t%s\n", secondary)
 		}
-		fmt.Println(issue.String())
-		status = 1
 	}
-	return status
+	if config.Summary {
+		writeSummary(w, stats.finish())
+	}
 }
 
-func outputToJSON(issues chan *Issue) int {
-	fmt.Println("[")
-	status := 0
+func outputToJSON(w io.Writer, issues chan *Issue) {
+	if config.JSONEnvelope {
+		fmt.Fprintln(w, "{")
+		fmt.Fprint(w, "\"issues\": ")
+	}
+	fmt.Fprintln(w, "[")
+	first := true
 	for issue := range issues {
-		if config.Errors && issue.Severity != Error {
-			continue
-		}
-		if status != 0 {
-			fmt.Printf(",\n")
+		if !first {
+			fmt.Fprintf(w, ",\n")
 		}
+		first = false
 		d, err := json.Marshal(issue)
 		kingpin.FatalIfError(err, "")
-		fmt.Printf("  %s", d)
-		status = 1
+		fmt.Fprintf(w, "  %s", d)
+	}
+	fmt.Fprintf(w, "\n]\n")
+	if config.JSONEnvelope {
+		d, err := json.Marshal(stats.finish())
+		kingpin.FatalIfError(err, "")
+		fmt.Fprintf(w, ",\n\"stats\": %s\n}\n", d)
 	}
-	fmt.Printf("\n]\n")
-	return status
 }
 
 func resolvePaths(paths, skip []string) []string {
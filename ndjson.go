@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event record types in newline-delimited JSON output.
+const (
+	linterStartEvent  = "linter_start"
+	linterFinishEvent = "linter_finish"
+	linterErrorEvent  = "linter_error"
+	issueEvent        = "issue"
+	summaryEvent      = "summary"
+)
+
+// linterEvent records the progress of a linter. ID identifies the partition of
+// paths being linted, as a linter may be run more than once.
+type linterEvent struct {
+	Type    string  `json:"type"`
+	Linter  string  `json:"linter"`
+	ID      int     `json:"id,omitempty"`
+	Elapsed float64 `json:"elapsed,omitempty"`
+	Message string  `json:"message,omitempty"`
+}
+
+type summaryRecord struct {
+	Type    string  `json:"type"`
+	Issues  int     `json:"issues"`
+	Errors  int     `json:"errors"`
+	Elapsed float64 `json:"elapsed"`
+	Status  int     `json:"status"`
+}
+
+// eventStream writes issues and linter events as newline-delimited JSON, one
+// record per line, as they occur.
+type eventStream struct {
+	lock   sync.Mutex
+	w      io.Writer
+	issues int
+	errors int
+}
+
+// events receives linter events. It is nil unless newline-delimited JSON
+// output is selected.
+var events *eventStream
+
+func newEventStream(w io.Writer) *eventStream {
+	return &eventStream{w: w}
+}
+
+func (e *eventStream) write(record interface{}) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		warning("failed to encode %v: %s", record, err)
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if _, err := e.w.Write(append(data, '\n')); err != nil {
+		warning("failed to write event: %s", err)
+	}
+}
+
+// linterStarted records that a linter has started linting a partition. It
+// does nothing if e is nil.
+func (e *eventStream) linterStarted(linter string, id int) {
+	if e == nil {
+		return
+	}
+	e.write(&linterEvent{Type: linterStartEvent, Linter: linter, ID: id})
+}
+
+// linterFinished records that a linter has finished linting a partition. It
+// does nothing if e is nil.
+func (e *eventStream) linterFinished(linter string, id int, elapsed time.Duration) {
+	if e == nil {
+		return
+	}
+	e.write(&linterEvent{Type: linterFinishEvent, Linter: linter, ID: id, Elapsed: elapsed.Seconds()})
+}
+
+// linterFailed records a linter error. It does nothing if e is nil.
+func (e *eventStream) linterFailed(linter string, err error) {
+	if e == nil {
+		return
+	}
+	e.lock.Lock()
+	e.errors++
+	e.lock.Unlock()
+	e.write(&linterEvent{Type: linterErrorEvent, Linter: linter, Message: err.Error()})
+}
+
+func (e *eventStream) issue(issue *Issue) {
+	data, err := json.Marshal(issue)
+	if err != nil {
+		warning("failed to encode issue %s: %s", issue, err)
+		return
+	}
+	e.lock.Lock()
+	e.issues++
+	e.lock.Unlock()
+	// Add the record type to the issue's own encoding.
+	e.write(json.RawMessage(append([]byte(`{"type":"`+issueEvent+`",`), data[1:]...)))
+}
+
+func (e *eventStream) summary(elapsed time.Duration, status int) {
+	e.lock.Lock()
+	record := &summaryRecord{Type: summaryEvent, Issues: e.issues, Errors: e.errors, Elapsed: elapsed.Seconds(), Status: status}
+	e.lock.Unlock()
+	e.write(record)
+}
+
+// outputToNDJSON writes issues to w. Linter events are directed to the same
+// writer by openOutputs, in which case their stream is shared so that records
+// are not interleaved and the summary counts the issues.
+func outputToNDJSON(w io.Writer, issues chan *Issue) {
+	stream := events
+	if stream == nil || stream.w != w {
+		stream = newEventStream(w)
+	}
+	for issue := range issues {
+		stream.issue(issue)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventStream(t *testing.T) {
+	buf := &bytes.Buffer{}
+	stream := newEventStream(buf)
+	stream.linterStarted("vet", 1)
+	stream.issue(&Issue{Linter: "vet", Severity: Warning, Path: newIssuePath("", "a.go"), Line: 3, Message: "bad"})
+	stream.linterFinished("vet", 1, 1500*time.Millisecond)
+	stream.linterFailed("golint", errors.New("not installed"))
+	stream.summary(2*time.Second, 1)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{
+		`{"type":"linter_start","linter":"vet","id":1}`,
+		`{"type":"issue","linter":"vet","severity":"warning","path":"a.go","line":3,"col":0,"message":"bad","linters":["vet"]}`,
+		`{"type":"linter_finish","linter":"vet","id":1,"elapsed":1.5}`,
+		`{"type":"linter_error","linter":"golint","message":"not installed"}`,
+		`{"type":"summary","issues":1,"errors":1,"elapsed":2,"status":1}`,
+	}, lines)
+	for _, line := range lines {
+		require.True(t, json.Valid([]byte(line)), line)
+	}
+}
+
+func TestNilEventStream(t *testing.T) {
+	var stream *eventStream
+	stream.linterStarted("vet", 1)
+	stream.linterFinished("vet", 1, time.Second)
+	stream.linterFailed("vet", errors.New("failed"))
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"go/build"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// fixUnmatchedDirectives removes linters that ran but did not match any issue
+// from nolint directives in Go source files. Directives left with no linters
+// are deleted. Bare directives, expired directives, linters that did not run
+// to completion, generated files, and files that linters do not analyse by
+// default are left untouched.
+func fixUnmatchedDirectives(directives *directiveParser) {
+	if directives.lintersRun == nil {
+		debug("nolint: not fixing directives, linters did not all complete")
+		return
+	}
+	for path, ranges := range directives.files {
+		if directives.isGeneratedPath(path) {
+			debug("nolint: not fixing directives in generated file %s", path)
+			continue
+		}
+		if !analysedPath(path) {
+			debug("nolint: not fixing directives in %s, it is not linted by default", path)
+			continue
+		}
+		edits := []directiveEdit{}
+		for _, ignore := range ranges {
+			if edit, ok := unmatchedDirectiveEdit(ignore, directives.lintersRun); ok {
+				edits = append(edits, edit)
+			}
+		}
+		if len(edits) == 0 {
+			continue
+		}
+		if err := applyDirectiveEdits(path, edits); err != nil {
+			warning("failed to fix nolint directives in %s: %s", path, err)
+		}
+	}
+}
+
+// analysedPath returns true if linters analyse the file at path. Test files are
+// only linted with --tests, and files excluded from the build by their name or
+// build constraints are not type checked at all.
+func analysedPath(path string) bool {
+	if strings.HasSuffix(path, "_test.go") && !config.Test {
+		return false
+	}
+	match, err := build.Default.MatchFile(filepath.Dir(path), filepath.Base(path))
+	return err == nil && match
+}
+
+// directiveEdit replaces a directive comment. An empty replacement deletes it.
+type directiveEdit struct {
+	ignore      *ignoredRange
+	replacement string
+}
+
+func unmatchedDirectiveEdit(ignore *ignoredRange, lintersRun *stringSet) (directiveEdit, bool) {
+	if ignore.syntax != nolintSyntax || ignore.text == "" || len(ignore.linters) == 0 || ignore.expired {
+		return directiveEdit{}, false
+	}
+	keep := []string{}
+	for _, linter := range ignore.linters {
+		matched := ignore.matchedLinters != nil && ignore.matchedLinters.contains(linter)
+		if matched || !lintersRun.contains(linter) {
+			keep = append(keep, linter)
+		}
+	}
+	if len(keep) == len(ignore.linters) {
+		return directiveEdit{}, false
+	}
+	edit := directiveEdit{ignore: ignore}
+	if len(keep) > 0 {
+		edit.replacement = rewriteNolintComment(ignore.text, keep)
+	}
+	return edit, true
+}
+
+// rewriteNolintComment replaces the linters named in a nolint comment,
+// preserving its spacing, expiry date and reason.
+func rewriteNolintComment(text string, linters []string) string {
+	i := strings.Index(text, "nolint:")
+	if i < 0 {
+		return text
+	}
+	head, body := text[:i+7], text[i+7:]
+	tail := ""
+	if j := strings.Index(body, "//"); j >= 0 {
+		body, tail = body[:j], body[j:]
+	}
+	separator := ","
+	if strings.Contains(body, ", ") {
+		separator = ", "
+	}
+	leading := body[:len(body)-len(strings.TrimLeftFunc(body, unicode.IsSpace))]
+	trailing := body[len(strings.TrimRightFunc(body, unicode.IsSpace)):]
+	out := head + leading + strings.Join(linters, separator)
+	if match := nolintUntilRegexp.FindString(body); match != "" {
+		out += " " + match
+	}
+	if tail != "" {
+		if trailing == "" {
+			trailing = " "
+		}
+		out += trailing + tail
+	}
+	return out
+}
+
+// applyDirectiveEdits rewrites a source file. The file is reformatted if it
+// was gofmt'd to begin with.
+func applyDirectiveEdits(path string, edits []directiveEdit) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	formatted, err := format.Source(source)
+	wasFormatted := err == nil && bytes.Equal(formatted, source)
+
+	// Apply from the end of the file so earlier offsets remain valid.
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].ignore.startOffset > edits[j].ignore.startOffset
+	})
+	out := source
+	for _, edit := range edits {
+		start, end := edit.ignore.startOffset, edit.ignore.endOffset
+		if end > len(out) || string(out[start:end]) != edit.ignore.text {
+			debug("nolint: %s changed since it was parsed, not fixing %s", path, edit.ignore)
+			continue
+		}
+		if edit.replacement == "" {
+			start, end = directiveDeletionBounds(out, start, end)
+		}
+		debug("nolint: %s:%d: replacing %q with %q", path, edit.ignore.line, edit.ignore.text, edit.replacement)
+		out = append(append(out[:start:start], edit.replacement...), out[end:]...)
+	}
+	if wasFormatted {
+		if formatted, err := format.Source(out); err == nil {
+			out = formatted
+		}
+	}
+	return ioutil.WriteFile(path, out, info.Mode())
+}
+
+// directiveDeletionBounds extends the bounds of a comment being deleted to
+// cover its whole line if nothing else is on it, or otherwise the whitespace
+// preceding it.
+func directiveDeletionBounds(source []byte, start, end int) (int, int) {
+	lineStart := bytes.LastIndexByte(source[:start], '\n') + 1
+	lineEnd := len(source)
+	if i := bytes.IndexByte(source[end:], '\n'); i >= 0 {
+		lineEnd = end + i
+	}
+	before := bytes.TrimSpace(source[lineStart:start])
+	after := bytes.TrimSpace(source[end:lineEnd])
+	if len(before) == 0 && len(after) == 0 {
+		if lineEnd < len(source) {
+			lineEnd++
+		}
+		return lineStart, lineEnd
+	}
+	for start > lineStart && (source[start-1] == ' ' || source[start-1] == '\t') {
+		start--
+	}
+	return start, end
+}
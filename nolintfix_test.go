@@ -0,0 +1,149 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteNolintComment(t *testing.T) {
+	var testcases = []struct {
+		text     string
+		linters  []string
+		expected string
+	}{
+		{text: "// nolint: vet, errcheck", linters: []string{"vet"}, expected: "// nolint: vet"},
+		{text: "//nolint:vet,errcheck,golint", linters: []string{"vet", "golint"}, expected: "//nolint:vet,golint"},
+		{text: "// nolint: vet, errcheck // reason", linters: []string{"errcheck"}, expected: "// nolint: errcheck // reason"},
+		{text: "// nolint: vet, errcheck until=2030-01-01 // reason", linters: []string{"vet"}, expected: "// nolint: vet until=2030-01-01 // reason"},
+	}
+	for _, testcase := range testcases {
+		assert.Equal(t, testcase.expected, rewriteNolintComment(testcase.text, testcase.linters), testcase.text)
+	}
+}
+
+func TestFixUnmatchedDirectives(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	mkFile(t, tmpdir, "fix.go", `package foo
+
+// nolint: errcheck, vet // legacy
+func a() {}
+
+func b() {} // nolint: golint
+
+// nolint: vet, megacheck
+func c() {}
+
+// nolint
+func d() {}
+`)
+
+	directives := newDirectiveParser()
+	issue := &Issue{Path: newIssuePath(tmpdir, "fix.go"), Line: 4, Linter: "errcheck"}
+	require.True(t, directives.IsIgnored(issue))
+	// megacheck did not run so its name is kept.
+	directives.lintersRun = newStringSet("errcheck", "vet", "golint")
+	fixUnmatchedDirectives(directives)
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpdir, "fix.go"))
+	require.NoError(t, err)
+	assert.Equal(t, `package foo
+
+// nolint: errcheck // legacy
+func a() {}
+
+func b() {}
+
+// nolint: megacheck
+func c() {}
+
+// nolint
+func d() {}
+`, string(data))
+}
+
+func TestFixUnmatchedDirectivesDeletesLine(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	mkFile(t, tmpdir, "fix.go", `package foo
+
+// a does nothing.
+// nolint: vet
+func a() {}
+`)
+
+	directives := newDirectiveParser()
+	require.NoError(t, directives.LoadFiles([]string{"."}))
+	directives.lintersRun = newStringSet("vet")
+	fixUnmatchedDirectives(directives)
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpdir, "fix.go"))
+	require.NoError(t, err)
+	assert.Equal(t, `package foo
+
+// a does nothing.
+func a() {}
+`, string(data))
+}
+
+func TestFixUnmatchedDirectivesSkipsGeneratedFiles(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	source := `// Code generated by mockgen. DO NOT EDIT.
+
+package foo
+
+// nolint: vet
+func a() {}
+`
+	mkFile(t, tmpdir, "gen.go", source)
+
+	directives := newDirectiveParser()
+	require.NoError(t, directives.LoadFiles([]string{"."}))
+	directives.lintersRun = newStringSet("vet")
+	fixUnmatchedDirectives(directives)
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpdir, "gen.go"))
+	require.NoError(t, err)
+	assert.Equal(t, source, string(data))
+}
+
+func TestFixUnmatchedDirectivesSkipsUnanalysedFiles(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.Test = false
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	source := "package foo\n\n// nolint: errcheck\nfunc a() {}\n"
+	tagged := "// +build ignore\n\n" + source
+	mkFile(t, tmpdir, "fix_test.go", source)
+	mkFile(t, tmpdir, "tagged.go", tagged)
+
+	directives := newDirectiveParser()
+	require.NoError(t, directives.LoadFiles([]string{"."}))
+	directives.lintersRun = newStringSet("errcheck")
+	fixUnmatchedDirectives(directives)
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpdir, "fix_test.go"))
+	require.NoError(t, err)
+	assert.Equal(t, source, string(data))
+	data, err = ioutil.ReadFile(filepath.Join(tmpdir, "tagged.go"))
+	require.NoError(t, err)
+	assert.Equal(t, tagged, string(data))
+
+	// Test files are linted with --tests.
+	config.Test = true
+	fixUnmatchedDirectives(directives)
+	data, err = ioutil.ReadFile(filepath.Join(tmpdir, "fix_test.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package foo\n\nfunc a() {}\n", string(data))
+}
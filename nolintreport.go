@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type nolintReportEntry struct {
+	Path    string   `json:"path"`
+	Line    int      `json:"line"`
+	Col     int      `json:"col"`
+	Syntax  string   `json:"syntax"`
+	Linters []string `json:"linters"`
+	Checks  []string `json:"checks,omitempty"`
+	Reason  string   `json:"reason"`
+	Until   string   `json:"until,omitempty"`
+	Matched bool     `json:"matched"`
+}
+
+func (e *nolintReportEntry) String() string {
+	linters := "all"
+	if len(e.Linters) > 0 {
+		linters = strings.Join(e.Linters, ",")
+	}
+	if len(e.Checks) > 0 {
+		linters += "(" + strings.Join(e.Checks, ",") + ")"
+	}
+	matched := "unmatched"
+	if e.Matched {
+		matched = "matched"
+	}
+	reason := e.Reason
+	if reason == "" {
+		reason = "(no reason)"
+	}
+	if e.Until != "" {
+		linters += " until=" + e.Until
+	}
+	return fmt.Sprintf("%s:%d:%d: %s:%s %s [%s]", e.Path, e.Line, e.Col, e.Syntax, linters, reason, matched)
+}
+
+// nolintReport lists every directive that was parsed, ordered by position.
+func nolintReport(directives *directiveParser) []*nolintReportEntry {
+	out := []*nolintReportEntry{}
+	for path, ranges := range directives.files {
+		for _, ignore := range ranges {
+			linters := ignore.linters
+			if linters == nil {
+				linters = []string{}
+			}
+			out = append(out, &nolintReportEntry{
+				Path:    path,
+				Line:    ignore.line,
+				Col:     ignore.col,
+				Syntax:  ignore.syntax,
+				Linters: linters,
+				Checks:  ignore.checks,
+				Reason:  ignore.reason,
+				Until:   ignore.until,
+				Matched: ignore.matched,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out
+}
+
+// stdoutNolintReport holds a report written to stdout until all issues have
+// been output, so that the two are not interleaved.
+var stdoutNolintReport = &bytes.Buffer{}
+
+func writeNolintReport(directives *directiveParser, filename string, format string) error {
+	var w io.Writer = stdoutNolintReport
+	if filename != "-" {
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint: errcheck
+		w = f
+	}
+
+	entries := nolintReport(directives)
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	for _, entry := range entries {
+		if _, err := fmt.Fprintln(w, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flushNolintReport writes a report held back by writeNolintReport to stdout.
+func flushNolintReport() {
+	if _, err := stdoutNolintReport.WriteTo(os.Stdout); err != nil {
+		warning("failed to write nolint report: %s", err)
+	}
+}
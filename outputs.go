@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// An outputFormat writes issues to w until the channel is closed.
+type outputFormat func(w io.Writer, issues chan *Issue)
+
+var outputFormats = map[string]outputFormat{
+	"text":           outputToText,
+	"json":           outputToJSON,
+	"ndjson":         outputToNDJSON,
+	"checkstyle":     outputToCheckstyle,
+	"github-actions": outputToGitHubActions,
+	"code-climate":   outputToCodeClimate,
+	"html":           outputToHTML,
+}
+
+func outputFormatNames() []string {
+	names := []string{}
+	for name := range outputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// output is a destination for issues, either a file or stdout.
+type output struct {
+	format   string
+	filename string
+	write    outputFormat
+	w        io.Writer
+	file     *os.File
+}
+
+func (o *output) String() string {
+	if o.filename == "" {
+		return o.format
+	}
+	return o.format + ":" + o.filename
+}
+
+// parseOutput parses an output of the form FORMAT[:FILE].
+func parseOutput(spec string) (*output, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	o := &output{format: parts[0]}
+	if len(parts) == 2 {
+		if parts[1] == "" {
+			return nil, fmt.Errorf("missing filename in output %q", spec)
+		}
+		o.filename = parts[1]
+	}
+	write, ok := outputFormats[o.format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q, expected one of %s", o.format, strings.Join(outputFormatNames(), ", "))
+	}
+	o.write = write
+	return o, nil
+}
+
+// outputsFromConfig returns the outputs given by --out. If there are none, the
+// output is selected by --json, --checkstyle etc. --html adds an HTML output.
+func outputsFromConfig(config *Config) ([]*output, error) {
+	specs := config.Out
+	if len(specs) == 0 {
+		specs = []string{legacyOutputFormat(config)}
+	}
+	if config.HTML != "" {
+		specs = append(specs, "html:"+config.HTML)
+	}
+	outputs := []*output{}
+	stdout := []string{}
+	ndjson := 0
+	for _, spec := range specs {
+		o, err := parseOutput(spec)
+		if err != nil {
+			return nil, err
+		}
+		if o.filename == "" {
+			stdout = append(stdout, o.format)
+		}
+		if o.format == "ndjson" {
+			ndjson++
+		}
+		outputs = append(outputs, o)
+	}
+	if len(stdout) > 1 {
+		return nil, fmt.Errorf("only one output can be written to stdout, got %s", strings.Join(stdout, ", "))
+	}
+	if ndjson > 1 {
+		return nil, fmt.Errorf("only one ndjson output is supported")
+	}
+	return outputs, nil
+}
+
+func legacyOutputFormat(config *Config) string {
+	switch {
+	case config.JSON:
+		return "json"
+	case config.NDJSON:
+		return "ndjson"
+	case config.GitHubActions:
+		return "github-actions"
+	case config.CodeClimate:
+		return "code-climate"
+	case config.Checkstyle:
+		return "checkstyle"
+	}
+	return "text"
+}
+
+// openOutputs creates output files. Linter events are directed to an ndjson
+// output, so outputs must be opened before linters are run.
+func openOutputs(outputs []*output) error {
+	for _, o := range outputs {
+		o.w = os.Stdout
+		if o.filename != "" {
+			file, err := os.Create(o.filename)
+			if err != nil {
+				closeOutputs(outputs)
+				return err
+			}
+			o.file = file
+			o.w = file
+		}
+		if o.format == "ndjson" {
+			events = newEventStream(o.w)
+		}
+	}
+	return nil
+}
+
+func closeOutputs(outputs []*output) {
+	for _, o := range outputs {
+		if o.file != nil {
+			if err := o.file.Close(); err != nil {
+				warning("failed to close %s: %s", o.filename, err)
+			}
+			o.file = nil
+		}
+	}
+}
+
+// writeOutputs sends each issue to every output, and returns the exit status:
+// 1 if there were any issues, otherwise 0. With --errors, only errors are
+// written.
+func writeOutputs(outputs []*output, issues chan *Issue) int {
+	wg := sync.WaitGroup{}
+	channels := make([]chan *Issue, len(outputs))
+	for i, o := range outputs {
+		channels[i] = make(chan *Issue, 1000000)
+		wg.Add(1)
+		go func(o *output, issues chan *Issue) {
+			defer wg.Done()
+			o.write(o.w, issues)
+		}(o, channels[i])
+	}
+	status := 0
+	for issue := range issues {
+		if config.Errors && issue.Severity != Error {
+			continue
+		}
+		stats.issue(issue)
+		for _, ch := range channels {
+			ch <- issue
+		}
+		status = 1
+	}
+	for _, ch := range channels {
+		close(ch)
+	}
+	wg.Wait()
+	return status
+}
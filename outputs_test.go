@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputsFromConfig(t *testing.T) {
+	var testcases = []struct {
+		config   Config
+		expected []string
+		err      bool
+	}{
+		{config: Config{}, expected: []string{"text"}},
+		{config: Config{Checkstyle: true}, expected: []string{"checkstyle"}},
+		{config: Config{JSON: true, HTML: "report.html"}, expected: []string{"json", "html:report.html"}},
+		{config: Config{Out: []string{"text", "json:out.json", "checkstyle:out.xml"}}, expected: []string{"text", "json:out.json", "checkstyle:out.xml"}},
+		{config: Config{Out: []string{"text", "json"}}, err: true},
+		{config: Config{Out: []string{"ndjson:a", "ndjson:b"}}, err: true},
+		{config: Config{Out: []string{"yaml"}}, err: true},
+		{config: Config{Out: []string{"json:"}}, err: true},
+	}
+	for _, testcase := range testcases {
+		outputs, err := outputsFromConfig(&testcase.config)
+		if testcase.err {
+			assert.Error(t, err, "%v", testcase.config.Out)
+			continue
+		}
+		require.NoError(t, err)
+		actual := []string{}
+		for _, o := range outputs {
+			actual = append(actual, o.String())
+		}
+		assert.Equal(t, testcase.expected, actual)
+	}
+}
+
+func TestWriteOutputs(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.Errors = true
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	outputs, err := outputsFromConfig(&Config{Out: []string{
+		"json:" + filepath.Join(tmpdir, "out.json"),
+		"github-actions:" + filepath.Join(tmpdir, "out.txt"),
+	}})
+	require.NoError(t, err)
+	require.NoError(t, openOutputs(outputs))
+
+	issues := make(chan *Issue, 2)
+	issues <- &Issue{Linter: "vet", Severity: Error, Path: newIssuePath(tmpdir, "a.go"), Line: 1, Message: "bad"}
+	issues <- &Issue{Linter: "golint", Severity: Warning, Path: newIssuePath(tmpdir, "a.go"), Line: 2, Message: "style"}
+	close(issues)
+	assert.Equal(t, 1, writeOutputs(outputs, issues))
+	closeOutputs(outputs)
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpdir, "out.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "[\n  {\"linter\":\"vet\",\"severity\":\"error\",\"path\":\"a.go\",\"line\":1,\"col\":0,\"message\":\"bad\",\"linters\":[\"vet\"]}\n]\n", string(data))
+	data, err = ioutil.ReadFile(filepath.Join(tmpdir, "out.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "::error file=a.go,line=1,title=vet::bad\n", string(data))
+}
+
+func TestWriteOutputsToNDJSONFile(t *testing.T) {
+	originalEvents := events
+	defer func() { events = originalEvents }()
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	outputs, err := outputsFromConfig(&Config{Out: []string{"ndjson:" + filepath.Join(tmpdir, "out.ndjson")}})
+	require.NoError(t, err)
+	require.NoError(t, openOutputs(outputs))
+
+	issues := make(chan *Issue, 1)
+	issues <- &Issue{Linter: "vet", Severity: Error, Path: newIssuePath(tmpdir, "a.go"), Line: 1, Message: "bad"}
+	close(issues)
+	assert.Equal(t, 1, writeOutputs(outputs, issues))
+	closeOutputs(outputs)
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpdir, "out.ndjson"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\"type\":\"issue\",\"linter\":\"vet\",\"severity\":\"error\",\"path\":\"a.go\",\"line\":1,\"col\":0,\"message\":\"bad\",\"linters\":[\"vet\"]}\n", string(data))
+}
+
+func TestOutputToNDJSONWritesToWriter(t *testing.T) {
+	originalEvents := events
+	defer func() { events = originalEvents }()
+	events = nil
+
+	buf := &bytes.Buffer{}
+	issues := make(chan *Issue, 1)
+	issues <- &Issue{Linter: "vet", Severity: Warning, Path: newIssuePath("", "a.go"), Line: 3, Message: "bad"}
+	close(issues)
+	outputToNDJSON(buf, issues)
+	assert.Equal(t, "{\"type\":\"issue\",\"linter\":\"vet\",\"severity\":\"warning\",\"path\":\"a.go\",\"line\":3,\"col\":0,\"message\":\"bad\",\"linters\":[\"vet\"]}\n", buf.String())
+}
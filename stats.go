@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reasons issues are suppressed, as counted in runStats.
+const (
+	suppressedByNolint       = "nolint"
+	suppressedByGenerated    = "generated"
+	suppressedByExclude      = "exclude"
+	suppressedByExcludeRules = "exclude_rules"
+	suppressedByLimits       = "limits"
+)
+
+// runStats collects statistics about a run for the summary footer and the
+// JSON envelope.
+type runStats struct {
+	lock  sync.Mutex
+	start time.Time
+
+	Issues     int               `json:"issues"`
+	ByLinter   map[string]int    `json:"by_linter"`
+	BySeverity map[string]int    `json:"by_severity"`
+	Files      int               `json:"files"`
+	Packages   int               `json:"packages"`
+	Failed     map[string]string `json:"failed_linters"`
+	Suppressed map[string]int    `json:"suppressed"`
+	Elapsed    float64           `json:"elapsed"`
+	// Files parsed for directives, and the time spent parsing them.
+	DirectiveFiles int     `json:"directive_files"`
+	DirectiveParse float64 `json:"directive_parse_time"`
+}
+
+// stats for the current run.
+var stats = newRunStats(time.Now())
+
+func newRunStats(start time.Time) *runStats {
+	return &runStats{
+		start:      start,
+		ByLinter:   map[string]int{},
+		BySeverity: map[string]int{},
+		Failed:     map[string]string{},
+		Suppressed: map[string]int{},
+	}
+}
+
+// linted records the number of files and packages being linted.
+func (s *runStats) linted(paths []string) {
+	files, err := pathsToFileGlobs(paths)
+	if err != nil {
+		debug("failed to count files: %s", err)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Files = len(files)
+	s.Packages = len(paths)
+}
+
+func (s *runStats) issue(issue *Issue) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Issues++
+	for _, linter := range issue.LinterNames() {
+		s.ByLinter[linter]++
+	}
+	s.BySeverity[string(issue.Severity)]++
+}
+
+func (s *runStats) suppressed(reason string, n int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Suppressed[reason] += n
+}
+
+func (s *runStats) directivesParsed(files int, elapsed time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.DirectiveFiles = files
+	s.DirectiveParse = elapsed.Seconds()
+}
+
+func (s *runStats) linterFailed(linter string, err error) {
+	reason := "failed"
+	if _, ok := err.(*deadlineError); ok {
+		reason = "timed out"
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// A timeout is more useful to report than a failure on another partition.
+	if s.Failed[linter] == "" || reason == "timed out" {
+		s.Failed[linter] = reason
+	}
+}
+
+// finish records the elapsed time and returns a copy of the statistics.
+func (s *runStats) finish() *runStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Elapsed = time.Since(s.start).Seconds()
+	return &runStats{
+		Issues:         s.Issues,
+		ByLinter:       copyCounts(s.ByLinter),
+		BySeverity:     copyCounts(s.BySeverity),
+		Files:          s.Files,
+		Packages:       s.Packages,
+		Failed:         copyStrings(s.Failed),
+		Suppressed:     copyCounts(s.Suppressed),
+		Elapsed:        s.Elapsed,
+		DirectiveFiles: s.DirectiveFiles,
+		DirectiveParse: s.DirectiveParse,
+	}
+}
+
+func copyCounts(m map[string]int) map[string]int {
+	out := make(map[string]int, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func copyStrings(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// formatCounts formats counts as "name: n", highest first.
+func formatCounts(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s: %d", name, counts[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeSummary writes a human readable summary of the run.
+func writeSummary(w io.Writer, s *runStats) {
+	fmt.Fprintf(w, "\n%d issues in %d files (%d packages) in %.1fs\n", s.Issues, s.Files, s.Packages, s.Elapsed)
+	if len(s.BySeverity) > 0 {
+		fmt.Fprintf(w, "  by severity: %s\n", formatCounts(s.BySeverity))
+	}
+	if len(s.ByLinter) > 0 {
+		fmt.Fprintf(w, "  by linter: %s\n", formatCounts(s.ByLinter))
+	}
+	if len(s.Suppressed) > 0 {
+		fmt.Fprintf(w, "  suppressed: %s\n", formatCounts(s.Suppressed))
+	}
+	if s.DirectiveFiles > 0 {
+		fmt.Fprintf(w, "  directives: parsed %d files in %.1fs\n", s.DirectiveFiles, s.DirectiveParse)
+	}
+	if len(s.Failed) > 0 {
+		names := make([]string, 0, len(s.Failed))
+		for name := range s.Failed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, fmt.Sprintf("%s (%s)", name, s.Failed[name]))
+		}
+		fmt.Fprintf(w, "  failed linters: %s\n", strings.Join(parts, ", "))
+	}
+}
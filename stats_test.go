@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStatsSummary(t *testing.T) {
+	s := newRunStats(time.Now().Add(-2 * time.Second))
+	s.Files, s.Packages = 10, 3
+	s.issue(&Issue{Linter: "vet", Severity: Error})
+	s.issue(&Issue{Linter: "golint", Severity: Warning})
+	s.issue(&Issue{Linter: "golint", Severity: Warning})
+	s.suppressed(suppressedByNolint, 4)
+	s.linterFailed("gotype", errors.New("failed"))
+	s.linterFailed("errcheck", &deadlineError{linter: "errcheck"})
+	s.linterFailed("errcheck", errors.New("failed"))
+	s.directivesParsed(12, 300*time.Millisecond)
+
+	summary := s.finish()
+	assert.InDelta(t, 2, summary.Elapsed, 1)
+	summary.Elapsed = 2
+	buf := &bytes.Buffer{}
+	writeSummary(buf, summary)
+	assert.Equal(t, `
+3 issues in 10 files (3 packages) in 2.0s
+  by severity: warning: 2, error: 1
+  by linter: golint: 2, vet: 1
+  suppressed: nolint: 4
+  directives: parsed 12 files in 0.3s
+  failed linters: errcheck (timed out), gotype (failed)
+`, buf.String())
+}
+
+func TestOutputToJSONEnvelope(t *testing.T) {
+	originalConfig := *config
+	originalStats := stats
+	defer func() { config = &originalConfig; stats = originalStats }()
+	config.JSONEnvelope = true
+	stats = newRunStats(time.Now())
+
+	issues := make(chan *Issue, 1)
+	issue := &Issue{Linter: "vet", Severity: Warning, Path: newIssuePath("", "a.go"), Line: 1, Message: "bad"}
+	stats.issue(issue)
+	issues <- issue
+	close(issues)
+	buf := &bytes.Buffer{}
+	outputToJSON(buf, issues)
+
+	var envelope struct {
+		Issues []map[string]interface{} `json:"issues"`
+		Stats  map[string]interface{}   `json:"stats"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &envelope), buf.String())
+	require.Len(t, envelope.Issues, 1)
+	assert.Equal(t, "vet", envelope.Issues[0]["linter"])
+	assert.Equal(t, float64(1), envelope.Stats["issues"])
+	assert.Equal(t, map[string]interface{}{"vet": float64(1)}, envelope.Stats["by_linter"])
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// lineWriter is an io.Writer that calls fn for each complete line written to
+// it. The trailing newline is not included.
+type lineWriter struct {
+	fn      func([]byte)
+	partial []byte
+}
+
+func newLineWriter(fn func([]byte)) *lineWriter {
+	return &lineWriter{fn: fn}
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			break
+		}
+		if len(l.partial) > 0 {
+			l.partial = append(l.partial, p[:i]...)
+			l.fn(l.partial)
+			l.partial = l.partial[:0]
+		} else {
+			l.fn(p[:i])
+		}
+		p = p[i+1:]
+	}
+	l.partial = append(l.partial, p...)
+	return n, nil
+}
+
+// Flush passes any remaining unterminated line to fn.
+func (l *lineWriter) Flush() {
+	if len(l.partial) > 0 {
+		l.fn(l.partial)
+		l.partial = nil
+	}
+}
+
+// Discard drops any remaining unterminated line.
+func (l *lineWriter) Discard() {
+	l.partial = nil
+}
+
+// outputLimit is the number of bytes of output retained from a linter,
+// shared between its output streams. A limit of 0 disables the limit.
+type outputLimit struct {
+	lock    sync.Mutex
+	limit   int64
+	written int64
+}
+
+func newOutputLimit(limit int64) *outputLimit {
+	return &outputLimit{limit: limit}
+}
+
+// take reserves up to n bytes of the limit, returning the number reserved.
+func (l *outputLimit) take(n int64) int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.limit > 0 && n > l.limit-l.written {
+		n = l.limit - l.written
+	}
+	l.written += n
+	return n
+}
+
+// limitWriter is an io.Writer that silently discards everything written to it
+// once an outputLimit is reached. Output is truncated after the last complete
+// line within the limit.
+type limitWriter struct {
+	w         io.Writer
+	limit     *outputLimit
+	truncated bool
+}
+
+func newLimitWriter(w io.Writer, limit *outputLimit) *limitWriter {
+	return &limitWriter{w: w, limit: limit}
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if l.truncated {
+		return n, nil
+	}
+	if allowed := l.limit.take(int64(n)); allowed < int64(n) {
+		p = p[:bytes.LastIndexByte(p[:allowed], '\n')+1]
+		l.truncated = true
+	}
+	if _, err := l.w.Write(p); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// linterOutput routes the stdout and stderr of a linter process. Each stream
+// is split into lines, which are then either parsed for issues, classified as
+// linter errors by the linter's error pattern, or captured for reporting.
+type linterOutput struct {
+	lock     sync.Mutex
+	linter   *Linter
+	parse    func([]byte)
+	stdout   *outputStream
+	stderr   *outputStream
+	errors   []string
+	captured bytes.Buffer
+	// Set once the linter has been killed. Children of the linter may still
+	// hold its output open, but nothing more is parsed or captured.
+	stopped bool
+	// All output, retained for debugging if debug is set.
+	debug bool
+	all   bytes.Buffer
+}
+
+type outputStream struct {
+	parsed   bool
+	classify bool
+	lines    *lineWriter
+	limit    *limitWriter
+	// Output for multi-line patterns, parsed once the linter has exited.
+	buf bytes.Buffer
+}
+
+// newLinterOutput creates the output for a linter invocation. At most limit
+// bytes of output are retained from stdout and stderr combined.
+func newLinterOutput(linter *Linter, parse func([]byte), limit int64) *linterOutput {
+	o := &linterOutput{linter: linter, parse: parse, debug: config.Debug}
+	shared := newOutputLimit(limit)
+	o.stdout = o.newStream(linter.parsesStream(stdoutStream), false, shared)
+	o.stderr = o.newStream(linter.parsesStream(stderrStream), linter.errorRegex != nil, shared)
+	return o
+}
+
+func (o *linterOutput) newStream(parsed, classify bool, limit *outputLimit) *outputStream {
+	s := &outputStream{parsed: parsed, classify: classify}
+	s.lines = newLineWriter(func(line []byte) { o.line(s, line) })
+	s.limit = newLimitWriter(s.lines, limit)
+	return s
+}
+
+func (o *linterOutput) line(s *outputStream, line []byte) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.stopped {
+		return
+	}
+	if o.debug {
+		o.all.Write(line)
+		o.all.WriteByte('\n')
+	}
+	switch {
+	case s.classify && o.linter.errorRegex.Match(line):
+		o.errors = append(o.errors, string(line))
+	case !s.parsed:
+		o.captured.Write(line)
+		o.captured.WriteByte('\n')
+	case o.linter.multiLine():
+		s.buf.Write(line)
+		s.buf.WriteByte('\n')
+	default:
+		o.parse(line)
+	}
+}
+
+// Stop discards any further output. It is called when the linter is killed,
+// as the process copying its output may outlive executeLinter and the issue
+// channel.
+func (o *linterOutput) Stop() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.stopped = true
+}
+
+// Stdout returns the writer for the linter's stdout.
+func (o *linterOutput) Stdout() io.Writer {
+	return o.stdout.limit
+}
+
+// Stderr returns the writer for the linter's stderr.
+func (o *linterOutput) Stderr() io.Writer {
+	return o.stderr.limit
+}
+
+// Close flushes any partial lines and parses buffered output. It must only be
+// called once the linter process has exited. The partial line of a truncated
+// stream is incomplete, so it is discarded.
+func (o *linterOutput) Close() {
+	for _, s := range []*outputStream{o.stdout, o.stderr} {
+		if s.limit.truncated {
+			s.lines.Discard()
+		} else {
+			s.lines.Flush()
+		}
+		if s.buf.Len() > 0 {
+			o.parse(s.buf.Bytes())
+		}
+	}
+}
+
+// Truncated returns true if either stream exceeded the output limit.
+func (o *linterOutput) Truncated() bool {
+	return o.stdout.limit.truncated || o.stderr.limit.truncated
+}
+
+// Errors returns the lines matching the linter's error pattern.
+func (o *linterOutput) Errors() []string {
+	return o.errors
+}
+
+// Captured returns all output that was not parsed for issues. It may be called
+// while the linter is still running.
+func (o *linterOutput) Captured() string {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return strings.TrimSpace(o.captured.String())
+}
+
+// All returns all output, if it was retained for debugging.
+func (o *linterOutput) All() string {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return strings.TrimSpace(o.all.String())
+}
+
+// linterError is a failure reported by a linter, along with any of its output
+// that was not parsed for issues.
+type linterError struct {
+	linter   string
+	messages []string
+	output   string
+}
+
+func (e *linterError) Error() string {
+	msg := fmt.Sprintf("linter %s failed: %s", e.linter, strings.Join(e.messages, "; "))
+	if e.output != "" {
+		msg += "\n" + e.output
+	}
+	return msg
+}
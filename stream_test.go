@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineWriter(t *testing.T) {
+	lines := []string{}
+	w := newLineWriter(func(line []byte) {
+		lines = append(lines, string(line))
+	})
+	for _, chunk := range []string{"fir", "st\nsec", "ond\n", "\nthird"} {
+		n, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+	assert.Equal(t, []string{"first", "second", ""}, lines)
+	w.Flush()
+	assert.Equal(t, []string{"first", "second", "", "third"}, lines)
+}
+
+func TestLimitWriter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w := newLimitWriter(buf, newOutputLimit(8))
+	n, err := w.Write([]byte("ab\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.False(t, w.truncated)
+
+	n, err = w.Write([]byte("cd\nefgh\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 8, n)
+	assert.True(t, w.truncated)
+	assert.Equal(t, "ab\ncd\n", buf.String())
+
+	// Nothing more is written once truncated, even if it would fit.
+	_, err = w.Write([]byte("\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "ab\ncd\n", buf.String())
+}
+
+func TestLimitWriterNoLimit(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w := newLimitWriter(buf, newOutputLimit(0))
+	_, err := w.Write(bytes.Repeat([]byte("a"), 1024))
+	require.NoError(t, err)
+	assert.False(t, w.truncated)
+	assert.Equal(t, 1024, buf.Len())
+}
+
+func TestLinterOutputStreams(t *testing.T) {
+	linter, err := NewLinter("custom", LinterConfig{
+		Pattern:      "PATH:LINE:MESSAGE",
+		Streams:      "stdout",
+		ErrorPattern: `^can't load package`,
+	})
+	require.NoError(t, err)
+
+	parsed := []string{}
+	output := newLinterOutput(linter, func(line []byte) {
+		parsed = append(parsed, string(line))
+	}, 0)
+	_, err = output.Stdout().Write([]byte("a.go:1: first\na.go:2: sec"))
+	require.NoError(t, err)
+	_, err = output.Stderr().Write([]byte("warning: noise\ncan't load package: foo\n"))
+	require.NoError(t, err)
+	_, err = output.Stdout().Write([]byte("ond\n"))
+	require.NoError(t, err)
+	output.Close()
+
+	assert.Equal(t, []string{"a.go:1: first", "a.go:2: second"}, parsed)
+	assert.Equal(t, []string{"can't load package: foo"}, output.Errors())
+	assert.Equal(t, "warning: noise", output.Captured())
+}
+
+func TestLinterOutputMultiLine(t *testing.T) {
+	linter, err := NewLinter("custom", LinterConfig{Pattern: `(?P<message>a\nb)`})
+	require.NoError(t, err)
+
+	parsed := []string{}
+	output := newLinterOutput(linter, func(out []byte) {
+		parsed = append(parsed, string(out))
+	}, 0)
+	_, err = output.Stdout().Write([]byte("a\nb\n"))
+	require.NoError(t, err)
+	_, err = output.Stderr().Write([]byte("c\n"))
+	require.NoError(t, err)
+	assert.Empty(t, parsed)
+	output.Close()
+	assert.Equal(t, []string{"a\nb\n", "c\n"}, parsed)
+}
+
+func TestLinterOutputTruncatedDiscardsPartialLine(t *testing.T) {
+	linter, err := NewLinter("custom", LinterConfig{Pattern: "PATH:LINE:MESSAGE"})
+	require.NoError(t, err)
+
+	parsed := []string{}
+	output := newLinterOutput(linter, func(line []byte) {
+		parsed = append(parsed, string(line))
+	}, 20)
+	_, err = output.Stdout().Write([]byte("a.go:1: first\na.go:2: "))
+	require.NoError(t, err)
+	_, err = output.Stdout().Write([]byte("second\n"))
+	require.NoError(t, err)
+	output.Close()
+
+	assert.True(t, output.Truncated())
+	assert.Equal(t, []string{"a.go:1: first"}, parsed)
+}
+
+func TestLinterOutputLimitIsShared(t *testing.T) {
+	linter, err := NewLinter("custom", LinterConfig{Pattern: "PATH:LINE:MESSAGE", Streams: "stdout"})
+	require.NoError(t, err)
+
+	output := newLinterOutput(linter, func(line []byte) {}, 16)
+	_, err = output.Stdout().Write([]byte("a.go:1: first\n"))
+	require.NoError(t, err)
+	_, err = output.Stderr().Write([]byte("noise\n"))
+	require.NoError(t, err)
+	output.Close()
+
+	assert.True(t, output.Truncated())
+	assert.Equal(t, "", output.Captured())
+}
+
+func TestLinterOutputStop(t *testing.T) {
+	linter, err := NewLinter("custom", LinterConfig{Pattern: "PATH:LINE:MESSAGE", Streams: "stdout"})
+	require.NoError(t, err)
+
+	parsed := []string{}
+	output := newLinterOutput(linter, func(line []byte) {
+		parsed = append(parsed, string(line))
+	}, 0)
+	_, err = output.Stdout().Write([]byte("a.go:1: first\n"))
+	require.NoError(t, err)
+	output.Stop()
+	_, err = output.Stdout().Write([]byte("a.go:2: late\n"))
+	require.NoError(t, err)
+	_, err = output.Stderr().Write([]byte("noise\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a.go:1: first"}, parsed)
+	assert.Equal(t, "", output.Captured())
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// formatFuncs are the functions available to --format templates.
+var formatFuncs = template.FuncMap{
+	"abs":            templateAbs,
+	"rel":            templateRel,
+	"relto":          templateRelTo,
+	"upper":          func(v interface{}) string { return strings.ToUpper(fmt.Sprint(v)) },
+	"lower":          func(v interface{}) string { return strings.ToLower(fmt.Sprint(v)) },
+	"trim":           func(v interface{}) string { return strings.TrimSpace(fmt.Sprint(v)) },
+	"pad":            templatePad,
+	"json":           templateJSON,
+	"sourceLine":     templateSourceLine,
+	"gitBlameAuthor": templateGitBlameAuthor,
+	"ruleURL":        templateRuleURL,
+}
+
+// templatePath converts an IssuePath or string to an absolute path.
+func templatePath(v interface{}) string {
+	if path, ok := v.(IssuePath); ok {
+		return path.Abs()
+	}
+	path := fmt.Sprint(v)
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+func templateAbs(path interface{}) string {
+	return templatePath(path)
+}
+
+func templateRel(path interface{}) string {
+	if path, ok := path.(IssuePath); ok {
+		return path.Relative()
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Sprint(path)
+	}
+	return templateRelTo(cwd, path)
+}
+
+// templateRelTo returns path relative to root, or the absolute path if it is
+// not under root.
+func templateRelTo(root string, path interface{}) string {
+	abs := templatePath(path)
+	rootAbs, err := filepath.Abs(root)
+	if err != nil {
+		return abs
+	}
+	rel, err := filepath.Rel(rootAbs, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return abs
+	}
+	return rel
+}
+
+// templatePad left-justifies v in width columns, or right-justifies it if
+// width is negative.
+func templatePad(width int, v interface{}) string {
+	if width < 0 {
+		return fmt.Sprintf("%*s", -width, fmt.Sprint(v))
+	}
+	return fmt.Sprintf("%-*s", width, fmt.Sprint(v))
+}
+
+func templateJSON(v interface{}) (string, error) {
+	out, err := json.Marshal(v)
+	return string(out), err
+}
+
+// Templates are executed concurrently, so the source and blame caches are
+// shared behind a lock.
+var (
+	templateCacheLock   sync.Mutex
+	templateSourceCache = sourceLines{}
+	templateBlameCache  = map[string]string{}
+)
+
+func templateSourceLine(issue *Issue) string {
+	templateCacheLock.Lock()
+	defer templateCacheLock.Unlock()
+	return templateSourceCache.line(issue)
+}
+
+// templateGitBlameAuthor returns the author of the line an issue was reported
+// on, or an empty string if it can not be determined.
+func templateGitBlameAuthor(issue *Issue) string {
+	if issue.Line < 1 {
+		return ""
+	}
+	path := issue.Path.Abs()
+	key := path + ":" + strconv.Itoa(issue.Line)
+	templateCacheLock.Lock()
+	author, ok := templateBlameCache[key]
+	templateCacheLock.Unlock()
+	if ok {
+		return author
+	}
+	author = gitBlameAuthor(path, issue.Line)
+	templateCacheLock.Lock()
+	templateBlameCache[key] = author
+	templateCacheLock.Unlock()
+	return author
+}
+
+func gitBlameAuthor(path string, line int) string {
+	cmd := exec.Command("git", "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", line, line), "--", filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+	out, err := cmd.Output()
+	if err != nil {
+		debug("git blame %s:%d failed: %s", path, line, err)
+		return ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if author := strings.TrimPrefix(scanner.Text(), "author "); author != scanner.Text() {
+			return author
+		}
+	}
+	return ""
+}
+
+// ruleDocURLs maps linters to the documentation for the checks they report,
+// formatted with the rule ID.
+// staticcheck, gosimple, stylecheck, unused and megacheck all report
+// staticcheck's check IDs.
+var ruleDocURLs = map[string]string{
+	"staticcheck": "https://staticcheck.io/docs/checks#%s",
+	"gosimple":    "https://staticcheck.io/docs/checks#%s",
+	"stylecheck":  "https://staticcheck.io/docs/checks#%s",
+	"unused":      "https://staticcheck.io/docs/checks#%s",
+	"megacheck":   "https://staticcheck.io/docs/checks#%s",
+}
+
+// templateRuleURL returns a link to the documentation for the check that
+// reported an issue, if known.
+func templateRuleURL(issue *Issue) string {
+	rule := issue.Rule()
+	if rule == "" {
+		return ""
+	}
+	for _, linter := range issue.LinterNames() {
+		if url, ok := ruleDocURLs[linter]; ok {
+			return fmt.Sprintf(url, rule)
+		}
+	}
+	return ""
+}
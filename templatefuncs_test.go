@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatFuncs(t *testing.T) {
+	dir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkFile(t, dir, "file.go", "package foo\n\tvar x = 1 \n")
+
+	issue := &Issue{
+		Linter:   "stylecheck",
+		Severity: Warning,
+		Path:     newIssuePath(dir, "file.go"),
+		Line:     2,
+		Col:      2,
+		Message:  "should omit type (ST1000)",
+	}
+	var testcases = []struct {
+		format   string
+		expected string
+	}{
+		{format: `{{.Path | abs}}`, expected: filepath.Join(dir, "file.go")},
+		{format: `{{.Path | rel}}`, expected: "file.go"},
+		{format: `{{.Path | relto "` + filepath.Dir(dir) + `"}}`, expected: filepath.Join(filepath.Base(dir), "file.go")},
+		{format: `{{.Path | relto "/nonexistent"}}`, expected: filepath.Join(dir, "file.go")},
+		{format: `{{.Severity | upper}} {{.Linter | upper | lower}}`, expected: "WARNING stylecheck"},
+		{format: `[{{pad 8 .Linter}}|{{.Line | pad -3}}]`, expected: "[stylecheck|  2]"},
+		{format: `[{{pad 3 .Line}}]`, expected: "[2  ]"},
+		{format: `[{{sourceLine . | trim}}]`, expected: "[var x = 1]"},
+		{format: `{{json .Message}}`, expected: `"should omit type (ST1000)"`},
+		{format: `{{ruleURL .}}`, expected: "https://staticcheck.io/docs/checks#ST1000"},
+		{format: `{{.Path}}({{.Line}},{{.Col}}): {{.Severity}} {{.Rule}}: {{.Message}}`, expected: "file.go(2,2): warning ST1000: should omit type (ST1000)"},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.format, func(t *testing.T) {
+			tmpl, err := template.New("output").Funcs(formatFuncs).Parse(testcase.format)
+			require.NoError(t, err)
+			buf := &bytes.Buffer{}
+			require.NoError(t, tmpl.Execute(buf, issue))
+			assert.Equal(t, testcase.expected, buf.String())
+		})
+	}
+}
+
+func TestTemplateRuleURL(t *testing.T) {
+	for _, linter := range []string{"staticcheck", "gosimple", "unused", "megacheck"} {
+		assert.Equal(t, "https://staticcheck.io/docs/checks#S1000", templateRuleURL(&Issue{Linter: linter, Message: "should use for range (S1000)"}), linter)
+	}
+}
+
+func TestTemplateSourceLineAfterChdir(t *testing.T) {
+	dir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkDir(t, dir, "a")
+	mkDir(t, dir, "b")
+	mkFile(t, dir, "a/file.go", "package a\n")
+	mkFile(t, dir, "b/file.go", "package b\n")
+
+	require.NoError(t, os.Chdir(filepath.Join(dir, "a")))
+	assert.Equal(t, "package a", templateSourceLine(&Issue{Path: newIssuePath(filepath.Join(dir, "a"), "file.go"), Line: 1}))
+	require.NoError(t, os.Chdir(filepath.Join(dir, "b")))
+	assert.Equal(t, "package b", templateSourceLine(&Issue{Path: newIssuePath(filepath.Join(dir, "b"), "file.go"), Line: 1}))
+}
+
+func TestTemplateRuleURLUnknown(t *testing.T) {
+	assert.Equal(t, "", templateRuleURL(&Issue{Linter: "staticcheck", Message: "no rule"}))
+	assert.Equal(t, "", templateRuleURL(&Issue{Linter: "golint", Message: "exported (ST1000)"}))
+}
+
+func TestGitBlameAuthorOutsideRepository(t *testing.T) {
+	dir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkGoFile(t, dir, "file.go")
+
+	assert.Equal(t, "", gitBlameAuthor(filepath.Join(dir, "file.go"), 1))
+}